@@ -0,0 +1,11 @@
+package api
+
+import "embed"
+
+//go:generate ./swagger-ui.sh
+
+// Docs contains swagger-ui page, vendored swagger-ui assets and swagger specification
+// embedded into the binary, so the docs don't load anything from the internet.
+//
+//go:embed index.html swagger.yml swagger-ui
+var Docs embed.FS
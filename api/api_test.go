@@ -0,0 +1,37 @@
+package api
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDocs_Spec(t *testing.T) {
+	data, err := Docs.ReadFile("swagger.yml")
+	require.NoError(t, err)
+
+	var spec map[string]any
+	require.NoError(t, yaml.Unmarshal(data, &spec))
+	assert.Contains(t, spec, "paths")
+}
+
+func TestDocs_Index(t *testing.T) {
+	data, err := Docs.ReadFile("index.html")
+	require.NoError(t, err)
+
+	// assets are served from the binary
+	assert.NotContains(t, string(data), "https://")
+	assert.Contains(t, string(data), `src="swagger-ui/swagger-ui-bundle.js"`)
+}
+
+func TestDocs_Assets(t *testing.T) {
+	for _, name := range []string{"swagger-ui/swagger-ui.css", "swagger-ui/swagger-ui-bundle.js", "swagger-ui/swagger-ui-standalone-preset.js"} {
+		info, err := fs.Stat(Docs, name)
+		if assert.NoError(t, err, "swagger-ui assets are vendored") {
+			assert.NotZero(t, info.Size(), name)
+		}
+	}
+}
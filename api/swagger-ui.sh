@@ -0,0 +1,16 @@
+#!/bin/sh
+# Vendors swagger-ui dist files of the version in swagger-ui/VERSION into swagger-ui, they are embedded into the binary.
+# Existing files are kept, remove them to update swagger-ui.
+set -e
+cd "$(dirname "$0")/swagger-ui"
+[ -f swagger-ui-bundle.js ] && exit 0
+
+version=$(cat VERSION)
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+curl -sSfL "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$version.tgz" | tar -xz -C "$tmp"
+for file in swagger-ui.css swagger-ui-bundle.js swagger-ui-standalone-preset.js favicon-32x32.png LICENSE NOTICE; do
+  if [ -f "$tmp/package/$file" ]; then
+    cp "$tmp/package/$file" .
+  fi
+done
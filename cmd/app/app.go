@@ -1,70 +1,110 @@
 package main
 
 import (
-	"bitbucket.org/creativeadvtech/project-template/internal"
 	"bitbucket.org/creativeadvtech/project-template/internal/config"
-	"bitbucket.org/creativeadvtech/project-template/internal/object-module"
-	"bitbucket.org/creativeadvtech/project-template/internal/repositories"
 	"bitbucket.org/creativeadvtech/project-template/pkg/database"
 	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
-	"bitbucket.org/creativeadvtech/project-template/pkg/rest"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/kelseyhightower/envconfig"
-	_ "github.com/lib/pq"
 	logs "github.com/sirupsen/logrus"
-	"net/http"
+	"io/fs"
 	"os"
-	"strconv"
 )
 
+// command is a subcommand of the app binary.
+type command struct {
+	name        string
+	args        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{name: "serve", description: "run the web application (default)", run: serve},
+	{name: "migrate", args: "up|down [N|-all]|goto N|status|force N", description: "manage the database schema", run: migrateCmd},
+	{name: "version", description: "print version and build information", run: version},
+	{name: "config", args: "print|docs [markdown|env]", description: "print effective configuration with secrets redacted or its docs", run: configCmd},
+}
+
+// configLoader loads configuration with the file and values set by global flags.
+var configLoader = config.RegisterFlags(flag.CommandLine)
+
 func main() {
-	// load app configuration from environment variables
-	var cfg config.Config
-	if err := envconfig.Process("", &cfg); err != nil {
+	flag.Usage = usage
+	flag.Parse()
+
+	if err := runCommand(flag.Args()); errors.Is(err, errUnknownCommand) {
+		fmt.Fprintln(os.Stderr, err)
+		usage()
+		os.Exit(2)
+	} else if err != nil {
 		logs.Fatal(err)
-		os.Exit(-1)
 	}
-	debug := cfg.LogLevel == "debug"
-	logging.Init(cfg.LogLevel)
+}
 
-	// set up postgres connection
-	logs.Info("Setting up Postgres database connection.")
-	db, err := database.NewDatabase(fmt.Sprintf(database.URLTemplate, cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName), debug)
-	if err != nil {
-		logs.Fatal(err)
-		os.Exit(-1)
+// errUnknownCommand is returned for a command not in commands.
+var errUnknownCommand = errors.New("unknown command")
+
+// runCommand runs the command named by the first argument with the rest of arguments, serve by default.
+func runCommand(args []string) error {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
-	// perform schema migration
-	logs.Info("Performing schema migration.")
-	if err = database.MigrateDBSchema(db.SqlDB(), "./migrations", cfg.DBName); err != nil {
-		logs.Errorf("Can't perform migration; error: %v", err)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
 	}
+	return fmt.Errorf("%w %q", errUnknownCommand, name)
+}
 
-	// configure router
-	router := chi.NewRouter()
-	router.Use(rest.RequestLogger(rest.NewLogger(cfg.LogLevel)))
-	router.Use(middleware.Recoverer)
-	router.Use(rest.SentryMiddleware(cfg.SentryDSN, cfg.SentryENV, debug, internal.AppVersion))
-
-	router.Handle("/", http.RedirectHandler("/docs/", http.StatusMovedPermanently))
-	router.Get("/docs/*", http.StripPrefix("/docs/", http.FileServer(http.Dir("./static"))).ServeHTTP)
-	router.Get("/status", rest.APIHandlerFunc(internal.Status(internal.AppVersion)))
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] <command> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-48s %s\n", cmd.name+" "+cmd.args, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags override configuration file and environment variables:\n")
+	flag.PrintDefaults()
+}
 
-	router.Mount("/v1", router.Group(func(r chi.Router) {
-		r.Mount("/objects", object_module.NewRest(object_module.NewModule(repositories.NewObjectRepository(db))))
-	}))
+// loadConfig loads app configuration and initializes global logger.
+func loadConfig() (config.Config, error) {
+	cfg, _, err := configLoader.Load()
+	if err != nil {
+		return cfg, err
+	}
+	logOpts := cfg.Logging()
+	if logOpts.Redactor, err = cfg.Redactor(); err != nil {
+		return cfg, err
+	}
+	logging.Init(logOpts)
+	return cfg, nil
+}
 
-	// initialize server
-	httpServer := &http.Server{
-		Addr:         ":" + strconv.Itoa(cfg.ServerPort),
-		Handler:      router,
-		WriteTimeout: cfg.ServerWriteTimeout,
-		ReadTimeout:  cfg.ServerReadTimeout,
+// openDatabase sets up Postgres database connection. It waits for the database to start up to DB_STARTUP_TIMEOUT.
+func openDatabase(cfg config.Config) (*database.DB, error) {
+	redactor, err := cfg.Redactor()
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.OpenDatabase(cfg.DatabaseDSN(), cfg.DatabasePool(), cfg.LogLevel == "debug", redactor)
+	if err != nil {
+		return nil, err
 	}
+	if err = db.PingWithRetry(context.Background(), cfg.DatabaseRetry()); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
 
-	logs.Info("Serving the web application...")
-	logs.Fatal(httpServer.ListenAndServe())
+// assetsFS returns embedded file system unless dir is set, e.g. to edit assets without rebuilding during development.
+func assetsFS(embedded fs.FS, dir string) fs.FS {
+	if dir == "" {
+		return embedded
+	}
+	return os.DirFS(dir)
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRunCommand(t *testing.T) {
+	t.Run("unknown command", func(t *testing.T) {
+		err := runCommand([]string{"drop", "all"})
+		assert.ErrorIs(t, err, errUnknownCommand)
+		assert.EqualError(t, err, `unknown command "drop"`)
+	})
+
+	t.Run("command arguments", func(t *testing.T) {
+		err := runCommand([]string{"migrate", "down", "0"})
+		assert.EqualError(t, err, "invalid number of migrations 0")
+	})
+}
+
+func TestPrintBuildInfo(t *testing.T) {
+	var buf bytes.Buffer
+	info := internal.BuildInfo{Version: "1.2.0", GitCommit: "0123456789abcdef", Dirty: true, BuildTime: "2023-01-02T03:04:05Z", GoVersion: "go1.19"}
+
+	require.NoError(t, printBuildInfo(&buf, info))
+
+	assert.Equal(t, "version: 1.2.0\ncommit: 0123456789abcdef\ndirty: true\nbuilt: 2023-01-02T03:04:05Z\ngo: go1.19\n", buf.String())
+}
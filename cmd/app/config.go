@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal/config"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+const configUsage = "usage: config print|docs [markdown|env]"
+
+// configCmd works with app configuration.
+func configCmd(args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "print":
+		return printConfig()
+	case len(args) == 1 && args[0] == "docs", len(args) == 2 && args[0] == "docs" && args[1] == "markdown":
+		return config.WriteMarkdown(os.Stdout)
+	case len(args) == 2 && args[0] == "docs" && args[1] == "env":
+		return config.WriteEnvExample(os.Stdout)
+	}
+	return errors.New(configUsage)
+}
+
+// printConfig prints effective configuration with secrets redacted.
+func printConfig() error {
+	_, settings, err := configLoader.Load()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, s := range settings {
+		source := s.Source
+		if s.File != "" {
+			source += " " + s.File
+		}
+		fmt.Fprintf(w, "%s=%s\t# %s\n", s.Key, s.Value, source)
+	}
+	return w.Flush()
+}
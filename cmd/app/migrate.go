@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/migrations"
+	"bitbucket.org/creativeadvtech/project-template/pkg/database"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	logs "github.com/sirupsen/logrus"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate [-migrations dir] [-module name] up|down [N|-all]|goto N|status|force N"
+
+// migrateCmd manages the database schema.
+func migrateCmd(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	migrationsDir := flags.String("migrations", "", "path to migrations directory to use instead of embedded migrations")
+	module := flags.String("module", "app", "name of the module which migrations to manage")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	action, err := parseMigrateAction(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var set migrationSet
+	for _, s := range migrationSets(assetsFS(migrations.FS, *migrationsDir), newModules(db)) {
+		if s.name == *module {
+			set = s
+		}
+	}
+	if set.fs == nil {
+		return fmt.Errorf("module %q has no migrations", *module)
+	}
+
+	ctx := context.Background()
+	return database.WithMigrationLock(ctx, db.SqlDB(), cfg.DBName, cfg.MigrationLockTimeout, func(conn *sql.Conn) error {
+		m, err := database.NewMigrator(ctx, conn, set.fs, cfg.DBName, set.table)
+		if err != nil {
+			return err
+		}
+		m.LockTimeout = cfg.MigrationLockTimeout
+
+		if err = action.run(m); errors.Is(err, migrate.ErrNoChange) {
+			logs.Info("No change.")
+		} else if err != nil {
+			return err
+		}
+		return printSchemaVersion(m)
+	})
+}
+
+// migrateAction is a parsed migrate subcommand.
+type migrateAction struct {
+	// name is up, down, goto, force or status
+	name string
+	// version is the target version of goto and force or the number of migrations rolled back by down
+	version int
+	// all rolls back all migrations
+	all bool
+}
+
+// parseMigrateAction parses migrate subcommand and its arguments.
+func parseMigrateAction(args []string) (migrateAction, error) {
+	if len(args) == 0 {
+		return migrateAction{}, errors.New(migrateUsage)
+	}
+	action, args := migrateAction{name: args[0]}, args[1:]
+	var err error
+	switch action.name {
+	case "up", "status":
+		if len(args) > 0 {
+			return action, errors.New(migrateUsage)
+		}
+	case "down":
+		// one migration is rolled back by default, rolling back all of them drops the schema
+		if len(args) == 1 && args[0] == "-all" {
+			action.all = true
+			break
+		}
+		action.version = 1
+		if len(args) > 0 {
+			if action.version, err = versionArg(args); err != nil {
+				return action, err
+			}
+			if action.version < 1 {
+				return action, fmt.Errorf("invalid number of migrations %d", action.version)
+			}
+		}
+	case "goto":
+		if action.version, err = versionArg(args); err != nil {
+			return action, err
+		}
+		if action.version < 0 {
+			return action, fmt.Errorf("invalid version %d", action.version)
+		}
+	case "force":
+		// -1 forces no version
+		if action.version, err = versionArg(args); err != nil {
+			return action, err
+		}
+		if action.version < -1 {
+			return action, fmt.Errorf("invalid version %d", action.version)
+		}
+	default:
+		return action, errors.New(migrateUsage)
+	}
+	return action, nil
+}
+
+// run performs the action with the migrator.
+func (a migrateAction) run(m *migrate.Migrate) error {
+	switch {
+	case a.name == "up":
+		return m.Up()
+	case a.name == "down" && a.all:
+		return m.Down()
+	case a.name == "down":
+		return m.Steps(-a.version)
+	case a.name == "goto":
+		return m.Migrate(uint(a.version))
+	case a.name == "force":
+		return m.Force(a.version)
+	}
+	return nil
+}
+
+// versionArg parses migration version from the only argument.
+func versionArg(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New(migrateUsage)
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q: %w", args[0], err)
+	}
+	return version, nil
+}
+
+func printSchemaVersion(m *migrate.Migrate) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("version: none")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d\ndirty: %t\n", version, dirty)
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseMigrateAction(t *testing.T) {
+	tests := []struct {
+		args   []string
+		action migrateAction
+		err    string
+	}{
+		{args: []string{"up"}, action: migrateAction{name: "up"}},
+		{args: []string{"status"}, action: migrateAction{name: "status"}},
+		{args: []string{"down"}, action: migrateAction{name: "down", version: 1}},
+		{args: []string{"down", "3"}, action: migrateAction{name: "down", version: 3}},
+		{args: []string{"down", "-all"}, action: migrateAction{name: "down", all: true}},
+		{args: []string{"goto", "0"}, action: migrateAction{name: "goto"}},
+		{args: []string{"goto", "2"}, action: migrateAction{name: "goto", version: 2}},
+		{args: []string{"force", "-1"}, action: migrateAction{name: "force", version: -1}},
+		{args: []string{"force", "2"}, action: migrateAction{name: "force", version: 2}},
+		{args: nil, err: migrateUsage},
+		{args: []string{"drop"}, err: migrateUsage},
+		{args: []string{"up", "1"}, err: migrateUsage},
+		{args: []string{"down", "0"}, err: "invalid number of migrations 0"},
+		{args: []string{"down", "-1"}, err: "invalid number of migrations -1"},
+		{args: []string{"down", "all"}, err: `invalid version "all": strconv.Atoi: parsing "all": invalid syntax`},
+		{args: []string{"down", "1", "2"}, err: migrateUsage},
+		{args: []string{"goto"}, err: migrateUsage},
+		{args: []string{"goto", "-1"}, err: "invalid version -1"},
+		{args: []string{"force"}, err: migrateUsage},
+		{args: []string{"force", "-2"}, err: "invalid version -2"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			action, err := parseMigrateAction(tt.args)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.action, action)
+		})
+	}
+}
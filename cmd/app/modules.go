@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal"
+	"bitbucket.org/creativeadvtech/project-template/internal/object-module"
+	"bitbucket.org/creativeadvtech/project-template/pkg/database"
+	"bitbucket.org/creativeadvtech/project-template/pkg/health"
+	"context"
+	"io/fs"
+)
+
+// newModules returns feature modules mounted under /v1. Register new modules here.
+func newModules(db *database.DB) []internal.Module {
+	return []internal.Module{
+		object_module.New(db),
+	}
+}
+
+// migrationSet is a set of migrations tracked in its own migrations table.
+type migrationSet struct {
+	name  string
+	fs    fs.FS
+	table string
+}
+
+// migrationSets returns app migrations followed by migrations of the modules owning them.
+func migrationSets(appMigrations fs.FS, modules []internal.Module) []migrationSet {
+	sets := []migrationSet{{name: "app", fs: appMigrations}}
+	for _, m := range modules {
+		if provider, ok := m.(internal.MigrationsProvider); ok {
+			sets = append(sets, migrationSet{name: m.Name(), fs: provider.Migrations(), table: internal.MigrationsTable(m)})
+		}
+	}
+	return sets
+}
+
+// healthCheck returns readiness check of the migrations schema version.
+func (s migrationSet) healthCheck(db *database.DB) health.Check {
+	name := "migrations"
+	if s.table != "" {
+		name = s.name + ".migrations"
+	}
+	return health.Check{Name: name, Critical: true, Check: func(ctx context.Context) error {
+		return database.CheckSchema(ctx, db, s.table)
+	}}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/api"
+	"bitbucket.org/creativeadvtech/project-template/internal/app"
+	"bitbucket.org/creativeadvtech/project-template/internal/config"
+	"bitbucket.org/creativeadvtech/project-template/migrations"
+	"bitbucket.org/creativeadvtech/project-template/pkg/database"
+	"bitbucket.org/creativeadvtech/project-template/pkg/health"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/lib/pq"
+	logs "github.com/sirupsen/logrus"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serve runs the web application until SIGINT or SIGTERM is received. SIGHUP reloads configuration.
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	migrationsDir := flags.String("migrations", "", "path to migrations directory to use instead of embedded migrations")
+	docsDir := flags.String("docs", "", "path to docs directory to use instead of embedded docs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// set up postgres connection
+	logs.Info("Setting up Postgres database connection.")
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	modules := newModules(db)
+	sets := migrationSets(assetsFS(migrations.FS, *migrationsDir), modules)
+	migrator := dbMigrator{db: db, dbName: cfg.DBName, lockTimeout: cfg.MigrationLockTimeout}
+	if err = migrateOnStartup(cfg, migrator, sets); err != nil {
+		return err
+	}
+
+	var checks []health.Check
+	for _, set := range sets {
+		checks = append(checks, set.healthCheck(db))
+	}
+
+	a, err := app.New(cfg,
+		app.WithDB(db),
+		app.WithModules(modules...),
+		app.WithHealthChecks(checks...),
+		app.WithDocs(assetsFS(api.Docs, *docsDir)),
+		app.WithConfigLoader(func() (config.Config, error) {
+			cfg, _, err := configLoader.Load()
+			return cfg, err
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	// serve until SIGINT or SIGTERM is received
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err = a.Run(ctx); err != nil {
+		return err
+	}
+	logs.Info("Server stopped.")
+	return nil
+}
+
+// schemaMigrator migrates the schema of migration sets and reads their versions.
+type schemaMigrator interface {
+	migrate(set migrationSet) error
+	version(ctx context.Context, set migrationSet) (version uint, dirty bool, err error)
+}
+
+// dbMigrator migrates the database schema holding the migration lock.
+type dbMigrator struct {
+	db          *database.DB
+	dbName      string
+	lockTimeout time.Duration
+}
+
+func (m dbMigrator) migrate(set migrationSet) error {
+	return database.MigrateDBSchema(m.db.SqlDB(), set.fs, m.dbName, set.table, m.lockTimeout)
+}
+
+func (m dbMigrator) version(ctx context.Context, set migrationSet) (uint, bool, error) {
+	return database.SchemaVersion(ctx, m.db, set.table)
+}
+
+// migrateOnStartup performs schema migrations according to migration mode and reports the resulting schema versions.
+func migrateOnStartup(cfg config.Config, migrator schemaMigrator, sets []migrationSet) error {
+	for _, set := range sets {
+		log := logs.WithField("migrations", set.name)
+		switch cfg.MigrationMode {
+		case config.MigrationModeSkip:
+			log.Info("Schema migration is skipped.")
+		case config.MigrationModeFail, config.MigrationModeWarn:
+			log.Info("Performing schema migration.")
+			err := migrator.migrate(set)
+			if err != nil && cfg.MigrationMode == config.MigrationModeFail {
+				return fmt.Errorf("can't perform %s migration: %w", set.name, err)
+			} else if err != nil {
+				log.Warnf("Can't perform migration; error: %v", err)
+			}
+		default:
+			return fmt.Errorf("unknown migration mode %q", cfg.MigrationMode)
+		}
+
+		version, dirty, err := migrator.version(context.Background(), set)
+		if errors.Is(err, migrate.ErrNilVersion) {
+			log.Warn("Database schema has no migrations applied.")
+			continue
+		} else if err != nil {
+			return fmt.Errorf("can't read %s schema version: %w", set.name, err)
+		}
+		log.WithField("dirty", dirty).Infof("Database schema version: %d.", version)
+	}
+	return nil
+}
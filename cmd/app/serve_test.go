@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal/config"
+	"context"
+	"errors"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// fakeMigrator records migrated sets and fails migrations of the sets in errs.
+type fakeMigrator struct {
+	errs     map[string]error
+	migrated []string
+	versions []string
+}
+
+func (m *fakeMigrator) migrate(set migrationSet) error {
+	m.migrated = append(m.migrated, set.name)
+	return m.errs[set.name]
+}
+
+func (m *fakeMigrator) version(_ context.Context, set migrationSet) (uint, bool, error) {
+	m.versions = append(m.versions, set.name)
+	if set.name == "empty" {
+		return 0, false, migrate.ErrNilVersion
+	}
+	return 1, false, nil
+}
+
+func modeConfig(mode string) config.Config {
+	var cfg config.Config
+	cfg.MigrationMode = mode
+	return cfg
+}
+
+func TestMigrateOnStartup(t *testing.T) {
+	sets := []migrationSet{{name: "app"}, {name: "empty"}, {name: "objects"}}
+	failure := errors.New("can't acquire migration lock")
+
+	t.Run("fail stops at the first error", func(t *testing.T) {
+		migrator := &fakeMigrator{errs: map[string]error{"empty": failure}}
+
+		err := migrateOnStartup(modeConfig(config.MigrationModeFail), migrator, sets)
+
+		assert.ErrorIs(t, err, failure)
+		assert.EqualError(t, err, "can't perform empty migration: can't acquire migration lock")
+		assert.Equal(t, []string{"app", "empty"}, migrator.migrated)
+	})
+
+	t.Run("warn continues", func(t *testing.T) {
+		migrator := &fakeMigrator{errs: map[string]error{"app": failure}}
+
+		err := migrateOnStartup(modeConfig(config.MigrationModeWarn), migrator, sets)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"app", "empty", "objects"}, migrator.migrated)
+		assert.Equal(t, []string{"app", "empty", "objects"}, migrator.versions)
+	})
+
+	t.Run("skip only reads versions", func(t *testing.T) {
+		migrator := &fakeMigrator{}
+
+		err := migrateOnStartup(modeConfig(config.MigrationModeSkip), migrator, sets)
+
+		assert.NoError(t, err)
+		assert.Empty(t, migrator.migrated)
+		assert.Equal(t, []string{"app", "empty", "objects"}, migrator.versions)
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		err := migrateOnStartup(modeConfig("always"), &fakeMigrator{}, sets)
+
+		assert.EqualError(t, err, `unknown migration mode "always"`)
+	})
+}
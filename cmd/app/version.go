@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal"
+	"fmt"
+	"io"
+	"os"
+)
+
+// version prints app version and build information.
+func version([]string) error {
+	return printBuildInfo(os.Stdout, internal.GetBuildInfo())
+}
+
+// printBuildInfo writes build information, one field per line.
+func printBuildInfo(w io.Writer, info internal.BuildInfo) error {
+	_, err := fmt.Fprintf(w, "version: %s\ncommit: %s\ndirty: %t\nbuilt: %s\ngo: %s\n",
+		info.Version, info.GitCommit, info.Dirty, info.BuildTime, info.GoVersion)
+	return err
+}
@@ -0,0 +1,319 @@
+package app
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/api"
+	"bitbucket.org/creativeadvtech/project-template/internal"
+	"bitbucket.org/creativeadvtech/project-template/internal/config"
+	"bitbucket.org/creativeadvtech/project-template/pkg/database"
+	"bitbucket.org/creativeadvtech/project-template/pkg/health"
+	"bitbucket.org/creativeadvtech/project-template/pkg/metrics"
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	"bitbucket.org/creativeadvtech/project-template/pkg/rest"
+	"bitbucket.org/creativeadvtech/project-template/pkg/server"
+	"bitbucket.org/creativeadvtech/project-template/pkg/tracing"
+	"context"
+	"errors"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	logs "github.com/sirupsen/logrus"
+	"io/fs"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// App is the web application with configured router and server.
+type App struct {
+	// Handler is the router with all middlewares and routes.
+	Handler http.Handler
+	// Server serves Handler and releases app resources on shutdown.
+	Server    *server.Server
+	Liveness  *health.Registry
+	Readiness *health.Registry
+	BuildInfo internal.BuildInfo
+	// Metrics is the registry of Prometheus metrics, modules can register their own.
+	Metrics *prometheus.Registry
+
+	modules        []internal.Module
+	sentryHub      *sentry.Hub
+	requestLogger  *logs.Logger
+	redactor       *redact.Redactor
+	db             *database.DB
+	certReloader   *server.CertReloader
+	metricsServer  *server.Server
+	reloadInterval time.Duration
+	modulesStarted atomic.Bool
+
+	// cfg is the applied configuration, loadConfig reloads it
+	mu         sync.Mutex
+	cfg        config.Config
+	loadConfig func() (config.Config, error)
+}
+
+// Option is an optional app dependency.
+type Option func(*options)
+
+type options struct {
+	db           *database.DB
+	modules      []internal.Module
+	healthChecks []health.Check
+	docs         fs.FS
+	loadConfig   func() (config.Config, error)
+}
+
+// WithDB adds database readiness check. The database is closed on shutdown.
+func WithDB(db *database.DB) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithModules adds feature modules mounted under /v1.
+func WithModules(modules ...internal.Module) Option {
+	return func(o *options) {
+		o.modules = append(o.modules, modules...)
+	}
+}
+
+// WithHealthChecks adds readiness checks.
+func WithHealthChecks(checks ...health.Check) Option {
+	return func(o *options) {
+		o.healthChecks = append(o.healthChecks, checks...)
+	}
+}
+
+// WithDocs replaces embedded docs served under /docs/.
+func WithDocs(docs fs.FS) Option {
+	return func(o *options) {
+		o.docs = docs
+	}
+}
+
+// WithConfigLoader enables configuration reload on SIGHUP and with the admin API.
+func WithConfigLoader(load func() (config.Config, error)) Option {
+	return func(o *options) {
+		o.loadConfig = load
+	}
+}
+
+// New returns the app configured according to cfg and dependencies.
+func New(cfg config.Config, opts ...Option) (*App, error) {
+	o := options{docs: api.Docs}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	redactor, err := cfg.Redactor()
+	if err != nil {
+		return nil, err
+	}
+	logOpts := cfg.Logging()
+	logOpts.Redactor = redactor
+	app := &App{
+		BuildInfo:     internal.GetBuildInfo(),
+		modules:       o.modules,
+		requestLogger: rest.NewLoggerWithOptions(logOpts),
+		redactor:      redactor,
+		Metrics:       metrics.NewRegistry(),
+		db:            o.db,
+		cfg:           cfg,
+		loadConfig:    o.loadConfig,
+	}
+	app.sentryHub, err = rest.NewSentryHub(app.sentryOptions(cfg))
+	if err != nil {
+		return nil, err
+	}
+	tracingOpts := cfg.Tracing()
+	tracingOpts.ServiceVersion = app.BuildInfo.Release()
+	shutdownTracing, err := tracing.Init(context.Background(), tracingOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// initialize server
+	app.Server = server.New(&http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.ServerPort),
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+		MaxHeaderBytes:    cfg.ServerMaxHeaderBytes,
+	}, cfg.ServerShutdownDelay, cfg.ServerShutdownTimeout)
+	if cfg.TLSCertFile != "" {
+		app.Server.TLSConfig, app.certReloader, err = server.NewTLSConfig(server.TLSOptions{
+			CertFile:     cfg.TLSCertFile,
+			KeyFile:      cfg.TLSKeyFile,
+			MinVersion:   cfg.TLSMinVersion,
+			CipherPolicy: cfg.TLSCipherPolicy,
+			ClientCAFile: cfg.TLSClientCAFile,
+			ClientAuth:   cfg.TLSClientAuth,
+		})
+		if err != nil {
+			return nil, err
+		}
+		app.reloadInterval = cfg.TLSReloadInterval
+	}
+	// resources are released in order after in-flight requests are drained
+	app.Server.OnShutdown(func(ctx context.Context) error {
+		if !app.modulesStarted.Load() {
+			return nil
+		}
+		logs.Info("Stopping modules.")
+		return internal.StopModules(ctx, app.modules)
+	})
+	app.Server.OnShutdown(func(ctx context.Context) error {
+		logs.Info("Flushing Sentry events.")
+		deadline, _ := ctx.Deadline()
+		if !app.sentryHub.Flush(time.Until(deadline)) {
+			logs.Warn("Not all Sentry events were sent before the timeout.")
+		}
+		return nil
+	})
+	app.Server.OnShutdown(func(ctx context.Context) error {
+		logs.Info("Flushing trace spans.")
+		return shutdownTracing(ctx)
+	})
+	if o.db != nil {
+		app.Server.OnShutdown(func(_ context.Context) error {
+			logs.Info("Closing Postgres database connection.")
+			return o.db.Close()
+		})
+	}
+
+	// register health checks
+	app.Liveness = health.NewRegistry(cfg.HealthCacheTTL, cfg.HealthCheckTimeout)
+	app.Readiness = health.NewRegistry(cfg.HealthCacheTTL, cfg.HealthCheckTimeout)
+	app.Readiness.Register(health.Check{Name: "server", Critical: true, Check: func(context.Context) error {
+		if !app.Server.Ready() {
+			return errors.New("shutting down")
+		}
+		return nil
+	}})
+	if o.db != nil {
+		app.Readiness.Register(health.Check{Name: "db", Critical: true, Check: o.db.PingContext})
+	}
+	if o.db != nil && cfg.MetricsEnabled {
+		o.db.RegisterMetrics(app.Metrics, cfg.DBName)
+	}
+	if o.db != nil && cfg.TracingExporter != tracing.ExporterNone {
+		o.db.RegisterTracing(redactor)
+	}
+	for _, check := range o.healthChecks {
+		app.Readiness.Register(check)
+	}
+	internal.RegisterHealthChecks(app.Readiness, app.modules)
+
+	// configure router
+	router := chi.NewRouter()
+	router.Use(rest.RequestID)
+	router.Use(rest.AccessLogger(app.requestLogger, cfg.AccessLog()))
+	if cfg.MetricsEnabled {
+		router.Use(metrics.NewHTTP(app.Metrics).Middleware(router))
+	}
+	router.Use(middleware.Recoverer)
+	router.Use(tracing.Middleware)
+	router.Use(rest.SentryHubMiddleware(app.sentryHub))
+	router.Use(rest.ClientIdentityMiddleware)
+	bodyLog := cfg.BodyLog()
+	bodyLog.Redactor = redactor
+	router.Use(rest.BodyLogger(bodyLog))
+	router.Use(rest.BodyLimit(cfg.ServerMaxBodyBytes))
+
+	router.Handle("/", http.RedirectHandler("/docs/", http.StatusMovedPermanently))
+	router.Get("/docs/*", http.StripPrefix("/docs/", http.FileServer(http.FS(o.docs))).ServeHTTP)
+	router.Get("/status", rest.APIHandlerFunc(internal.Status(app.BuildInfo, app.Server.Ready)))
+	router.Get("/health/live", rest.APIHandlerFunc(health.Handler(app.Liveness)))
+	router.Get("/health/ready", rest.APIHandlerFunc(health.Handler(app.Readiness)))
+	router.Post("/admin/reload", rest.APIHandlerFunc(app.reloadHandler))
+	if cfg.MetricsEnabled && cfg.MetricsPort == 0 {
+		router.Handle(cfg.MetricsPath, metrics.Handler(app.Metrics))
+	} else if cfg.MetricsEnabled {
+		metricsRouter := http.NewServeMux()
+		metricsRouter.Handle(cfg.MetricsPath, metrics.Handler(app.Metrics))
+		app.metricsServer = server.New(&http.Server{
+			Addr:              ":" + strconv.Itoa(cfg.MetricsPort),
+			Handler:           metricsRouter,
+			ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		}, 0, cfg.ServerShutdownTimeout)
+	}
+
+	router.Mount("/v1", router.Group(func(r chi.Router) {
+		internal.MountModules(r, app.modules)
+	}))
+
+	app.Handler = router
+	app.Server.Handler = router
+	return app, nil
+}
+
+// Run starts modules and serves requests until ctx is done, then shuts the app down.
+// Metrics are served on a separate port when it's configured, Run returns after both servers are stopped.
+// The ports are bound before modules are started; when the app fails to start, resources are released with
+// shutdown hooks. The first error is returned.
+func (a *App) Run(ctx context.Context) error {
+	ln, metricsLn, err := a.listen()
+	if err == nil {
+		if err = internal.StartModules(ctx, a.modules); err != nil {
+			_ = ln.Close()
+			if metricsLn != nil {
+				_ = metricsLn.Close()
+			}
+		}
+	}
+	if err != nil {
+		if releaseErr := a.Server.Release(); releaseErr != nil {
+			logs.Errorf("Can't release resources; error: %v", releaseErr)
+		}
+		return err
+	}
+	a.modulesStarted.Store(true)
+
+	if a.certReloader != nil {
+		go a.certReloader.Watch(ctx, a.reloadInterval)
+	}
+	if a.loadConfig != nil {
+		go a.reloadOnSIGHUP(ctx)
+	}
+	if metricsLn == nil {
+		logs.WithField("release", a.BuildInfo.Release()).Info("Serving the web application...")
+		return a.Server.Serve(ctx, ln)
+	}
+
+	// the metrics server is stopped with the app, even when the app server fails
+	metricsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	metricsErr := make(chan error, 1)
+	logs.WithField("addr", a.metricsServer.Addr).Info("Serving metrics...")
+	go func() {
+		metricsErr <- a.metricsServer.Serve(metricsCtx, metricsLn)
+	}()
+	logs.WithField("release", a.BuildInfo.Release()).Info("Serving the web application...")
+	err = a.Server.Serve(ctx, ln)
+	cancel()
+	if mErr := <-metricsErr; mErr != nil {
+		logs.Errorf("Metrics server failed; error: %v", mErr)
+		if err == nil {
+			err = mErr
+		}
+	}
+	return err
+}
+
+// listen binds the app port and the metrics port when metrics are served separately.
+func (a *App) listen() (ln, metricsLn net.Listener, err error) {
+	if ln, err = net.Listen("tcp", a.Server.Addr); err != nil {
+		return nil, nil, err
+	}
+	if a.metricsServer != nil {
+		if metricsLn, err = net.Listen("tcp", a.metricsServer.Addr); err != nil {
+			_ = ln.Close()
+			return nil, nil, err
+		}
+	}
+	return ln, metricsLn, nil
+}
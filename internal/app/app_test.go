@@ -0,0 +1,267 @@
+package app
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal/config"
+	"bitbucket.org/creativeadvtech/project-template/internal/models"
+	"bitbucket.org/creativeadvtech/project-template/internal/object-module"
+	"bitbucket.org/creativeadvtech/project-template/pkg/common"
+	"bitbucket.org/creativeadvtech/project-template/pkg/database"
+	"bitbucket.org/creativeadvtech/project-template/pkg/rest"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testID common.UUID = "123e4567-e89b-12d3-a456-426655440000"
+
+// memoryRepository is an in-memory object repository.
+type memoryRepository struct {
+	mu      sync.Mutex
+	objects map[common.UUID]models.Object
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{objects: make(map[common.UUID]models.Object)}
+}
+
+func (r *memoryRepository) List(_ context.Context, _ common.Pagination) (*common.List[models.Object], error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := &common.List[models.Object]{}
+	for _, obj := range r.objects {
+		list.List = append(list.List, obj)
+	}
+	list.Count, list.Total = len(list.List), len(list.List)
+	return list, nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id common.UUID) (*models.Object, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	obj, ok := r.objects[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	return &obj, nil
+}
+
+func (r *memoryRepository) Create(_ context.Context, obj *models.Object) (*models.Object, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	obj.ID = testID
+	obj.CreatedAt = time.Date(2022, 07, 02, 00, 00, 00, 00, time.UTC)
+	obj.UpdatedAt = obj.CreatedAt
+	r.objects[obj.ID] = *obj
+	return obj, nil
+}
+
+func (r *memoryRepository) Update(_ context.Context, id common.UUID, obj *models.Object) (*models.Object, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.objects[id]
+	if !ok {
+		return nil, database.ErrNotFound
+	}
+	stored.Data = obj.Data
+	r.objects[id] = stored
+	return &stored, nil
+}
+
+func (r *memoryRepository) Delete(_ context.Context, id common.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.objects[id]; !ok {
+		return database.ErrNotFound
+	}
+	delete(r.objects, id)
+	return nil
+}
+
+func testConfig() config.Config {
+	var cfg config.Config
+	cfg.LogLevel = "error"
+	cfg.HealthCheckTimeout = time.Second
+	cfg.ServerShutdownTimeout = time.Second
+	return cfg
+}
+
+func newTestApp(t *testing.T) *httptest.Server {
+	a, err := New(testConfig(), WithModules(object_module.NewWithRepository(newMemoryRepository())))
+	require.NoError(t, err)
+	srv := httptest.NewServer(a.Handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+const testRequestID = "0b5a4ff4-5e3b-4f8b-a7b4-3f4f9f0b6d2a"
+
+func doRequest(t *testing.T, method, url string, body any) (int, string) {
+	var reader *bytes.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(js)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	req.Header.Set(rest.RequestIDHeader, testRequestID)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(resBody)
+}
+
+func TestApp_Objects(t *testing.T) {
+	srv := newTestApp(t)
+
+	code, body := doRequest(t, http.MethodPost, srv.URL+"/v1/objects", map[string]any{"data": "  some data "})
+	require.Equal(t, http.StatusOK, code, body)
+	assert.JSONEq(t, `{
+		"id": "123e4567-e89b-12d3-a456-426655440000",
+		"data": "some data",
+		"created_at": "2022-07-02T00:00:00Z",
+		"updated_at": "2022-07-02T00:00:00Z"
+	}`, body)
+
+	code, body = doRequest(t, http.MethodGet, srv.URL+"/v1/objects/"+string(testID), nil)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Contains(t, body, `"data":"some data"`)
+
+	code, body = doRequest(t, http.MethodDelete, srv.URL+"/v1/objects/"+string(testID), nil)
+	require.Equal(t, http.StatusOK, code, body)
+
+	code, body = doRequest(t, http.MethodGet, srv.URL+"/v1/objects/"+string(testID), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.JSONEq(t, `{"code": 404, "description": "object not found", "request_id": "`+testRequestID+`"}`, body)
+}
+
+func TestApp_Status(t *testing.T) {
+	srv := newTestApp(t)
+
+	code, body := doRequest(t, http.MethodGet, srv.URL+"/status", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "server isn't running, only the handler is served")
+	assert.Contains(t, body, `"status":"unavailable"`)
+
+	code, _ = doRequest(t, http.MethodGet, srv.URL+"/health/live", nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, body = doRequest(t, http.MethodGet, srv.URL+"/health/ready", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, `"error":"shutting down"`)
+}
+
+func TestApp_Metrics(t *testing.T) {
+	cfg := testConfig()
+	cfg.MetricsEnabled, cfg.MetricsPath = true, "/metrics"
+	a, err := New(cfg, WithModules(object_module.NewWithRepository(newMemoryRepository())))
+	require.NoError(t, err)
+	srv := httptest.NewServer(a.Handler)
+	defer srv.Close()
+
+	code, _ := doRequest(t, http.MethodGet, srv.URL+"/v1/objects/"+string(testID), nil)
+	require.Equal(t, http.StatusNotFound, code)
+	code, body := doRequest(t, http.MethodGet, srv.URL+"/metrics", nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/v1/objects/{ObjectID}",status="4xx"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+	assert.Contains(t, body, "process_cpu_seconds_total")
+}
+
+// lifecycleModule records starts and stops.
+type lifecycleModule struct {
+	calls *[]string
+}
+
+func (m lifecycleModule) Name() string {
+	return "lifecycle"
+}
+
+func (m lifecycleModule) Path() string {
+	return "/lifecycle"
+}
+
+func (m lifecycleModule) Router() http.Handler {
+	return http.NotFoundHandler()
+}
+
+func (m lifecycleModule) Start(context.Context) error {
+	*m.calls = append(*m.calls, "start")
+	return nil
+}
+
+func (m lifecycleModule) Stop(context.Context) error {
+	*m.calls = append(*m.calls, "stop")
+	return nil
+}
+
+// freePort returns a port free to listen on.
+func freePort(t *testing.T) int {
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestApp_Run(t *testing.T) {
+	t.Run("stops the metrics server and modules", func(t *testing.T) {
+		var calls []string
+		cfg := testConfig()
+		cfg.ServerPort = freePort(t)
+		cfg.MetricsEnabled, cfg.MetricsPath, cfg.MetricsPort = true, "/metrics", freePort(t)
+		a, err := New(cfg, WithModules(lifecycleModule{calls: &calls}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- a.Run(ctx) }()
+		require.Eventually(t, func() bool {
+			res, err := http.Get("http://" + net.JoinHostPort("localhost", strconv.Itoa(cfg.MetricsPort)) + "/metrics")
+			if err != nil {
+				return false
+			}
+			_ = res.Body.Close()
+			return res.StatusCode == http.StatusOK
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+
+		require.NoError(t, <-done)
+		assert.Equal(t, []string{"start", "stop"}, calls)
+		ln, err := net.Listen("tcp", a.metricsServer.Addr)
+		require.NoError(t, err, "the metrics server is stopped when Run returns")
+		_ = ln.Close()
+	})
+
+	t.Run("releases resources when the port is busy", func(t *testing.T) {
+		busy, err := net.Listen("tcp", ":0")
+		require.NoError(t, err)
+		defer busy.Close()
+		var calls []string
+		cfg := testConfig()
+		cfg.ServerPort = busy.Addr().(*net.TCPAddr).Port
+		a, err := New(cfg, WithModules(lifecycleModule{calls: &calls}))
+		require.NoError(t, err)
+		a.Server.OnShutdown(func(context.Context) error {
+			calls = append(calls, "release")
+			return nil
+		})
+
+		err = a.Run(context.Background())
+
+		assert.ErrorContains(t, err, "address already in use")
+		assert.Equal(t, []string{"release"}, calls, "modules aren't started")
+	})
+}
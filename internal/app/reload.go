@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal/config"
+	"bitbucket.org/creativeadvtech/project-template/pkg/rest"
+	"context"
+	"crypto/subtle"
+	"github.com/getsentry/sentry-go"
+	logs "github.com/sirupsen/logrus"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// ReloadResult lists configuration changes found on reload.
+type ReloadResult struct {
+	// Applied are changes applied without restart.
+	Applied []config.Change `json:"applied"`
+	// RestartRequired are changes applied on the next start.
+	RestartRequired []config.Change `json:"restart_required"`
+}
+
+// Reload loads configuration and applies the changes which don't require restart:
+// log levels, Sentry sample rates, database credentials and DSN for new connections and admin token.
+func (a *App) Reload() (ReloadResult, error) {
+	var result ReloadResult
+	if a.loadConfig == nil {
+		return result, nil
+	}
+	loaded, err := a.loadConfig()
+	if err != nil {
+		return result, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cfg := a.cfg
+	cfg.LogLevel = loaded.LogLevel
+	cfg.SentrySamplingConfig = loaded.SentrySamplingConfig
+	cfg.DBUser, cfg.DBPass, cfg.DBDSN = loaded.DBUser, loaded.DBPass, loaded.DBDSN
+	cfg.AdminConfig = loaded.AdminConfig
+
+	// prepare everything that can fail before applying anything
+	level, err := logs.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return result, err
+	}
+	var sentryClient *sentry.Client
+	if a.sentryOptions(cfg) != a.sentryOptions(a.cfg) {
+		if sentryClient, err = rest.NewSentryClient(a.sentryOptions(cfg)); err != nil {
+			return result, err
+		}
+	}
+	if a.db != nil && cfg.DatabaseDSN() != a.cfg.DatabaseDSN() {
+		if err := a.db.SetDSN(cfg.DatabaseDSN()); err != nil {
+			return result, err
+		}
+	}
+	if sentryClient != nil {
+		a.sentryHub.BindClient(sentryClient)
+	}
+	logs.SetLevel(level)
+	a.requestLogger.SetLevel(level)
+
+	result.Applied, result.RestartRequired = config.Diff(a.cfg, cfg), config.Diff(cfg, loaded)
+	a.cfg = cfg
+	for _, change := range result.Applied {
+		logs.WithFields(logs.Fields{"key": change.Key, "old": change.Old, "new": change.New}).Info("Configuration change is applied.")
+	}
+	for _, change := range result.RestartRequired {
+		logs.WithFields(logs.Fields{"key": change.Key, "old": change.Old, "new": change.New}).Warn("Configuration change requires restart.")
+	}
+	return result, nil
+}
+
+func (a *App) sentryOptions(cfg config.Config) rest.SentryOptions {
+	return rest.SentryOptions{
+		DSN:              cfg.SentryDSN,
+		Env:              cfg.SentryENV,
+		Debug:            cfg.LogLevel == "debug",
+		Release:          a.BuildInfo.Release(),
+		SampleRate:       cfg.SentrySampleRate,
+		TracesSampleRate: cfg.SentryTracesSampleRate,
+		Redactor:         a.redactor,
+	}
+}
+
+// reloadOnSIGHUP reloads configuration on SIGHUP until ctx is done.
+func (a *App) reloadOnSIGHUP(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logs.Info("Reloading configuration.")
+			if _, err := a.Reload(); err != nil {
+				logs.Errorf("Can't reload configuration; error: %v", err)
+			}
+		}
+	}
+}
+
+// reloadHandler reloads configuration. It requires `Authorization: Bearer <ADMIN_TOKEN>` header.
+func (a *App) reloadHandler(w http.ResponseWriter, r *http.Request) error {
+	a.mu.Lock()
+	token := a.cfg.AdminToken
+	a.mu.Unlock()
+	if token == "" || a.loadConfig == nil {
+		return rest.NotFoundErrorf("admin API is disabled")
+	}
+	auth := rest.ReadHeader(r, "Authorization")
+	given := strings.TrimPrefix(auth, "Bearer ")
+	if given == auth || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		return rest.UnauthorizedErrorf("invalid admin token")
+	}
+
+	result, err := a.Reload()
+	if err != nil {
+		return rest.BadRequestErrorf("can't reload configuration: %v", err).WithError(err)
+	}
+	return rest.WriteJSON(w, result, http.StatusOK)
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal/config"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logs "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Reload(t *testing.T) {
+	level := logs.GetLevel()
+	t.Cleanup(func() { logs.SetLevel(level) })
+
+	loaded := testConfig()
+	loaded.AdminToken = "secret"
+	a, err := New(testConfig(), WithConfigLoader(func() (config.Config, error) {
+		return loaded, nil
+	}))
+	require.NoError(t, err)
+	srv := httptest.NewServer(a.Handler)
+	t.Cleanup(srv.Close)
+
+	reload := func(token string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/admin/reload", nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	code, _ := reload("secret")
+	assert.Equal(t, http.StatusNotFound, code, "admin API is disabled until the token is loaded")
+
+	result, err := a.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []config.Change{{Key: "ADMIN_TOKEN", Old: "", New: "******"}}, result.Applied)
+	assert.Empty(t, result.RestartRequired)
+
+	loaded.LogLevel = "warning"
+	loaded.SentrySampleRate = 0.5
+	loaded.ServerPort = 9090
+	code, _ = reload("wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, body := reload("secret")
+	require.Equal(t, http.StatusOK, code, body)
+	assert.JSONEq(t, `{
+		"applied": [
+			{"key": "LOG_LEVEL", "old": "error", "new": "warning"},
+			{"key": "SENTRY_SAMPLE_RATE", "old": "0", "new": "0.5"}
+		],
+		"restart_required": [
+			{"key": "SERVER_PORT", "old": "0", "new": "9090"}
+		]
+	}`, body)
+	assert.Equal(t, logs.WarnLevel, logs.GetLevel())
+	assert.Equal(t, logs.WarnLevel, a.requestLogger.GetLevel())
+	assert.Equal(t, 0.5, a.sentryHub.Client().Options().SampleRate)
+}
@@ -1,11 +1,232 @@
 package config
 
-import "bitbucket.org/creativeadvtech/project-template/pkg/common"
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/common"
+	"bitbucket.org/creativeadvtech/project-template/pkg/database"
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	"bitbucket.org/creativeadvtech/project-template/pkg/rest"
+	"bitbucket.org/creativeadvtech/project-template/pkg/tracing"
+	"net/netip"
+	"time"
+)
 
-// Config is responsible for application startup configuration.
-// `envconfig` is a specific tag for https://github.com/kelseyhightower/envconfig package.
+// Config is responsible for application startup configuration. It's loaded with Loader.
+// `envconfig` tag is a configuration key, `default` is a default value, `required:"true"` requires a non-empty value,
+// `validate` is a validation rule, `desc` is a description for docs and `secret:"true"` redacts the value in dumps.
 type Config struct {
 	common.Config
+	LogConfig
+	RedactConfig
 	common.DbConfig
+	DatabaseConfig
 	common.SentryConfig
+	SentrySamplingConfig
+	AdminConfig
+	ServerConfig
+	MigrationConfig
+	HealthConfig
+	MetricsConfig
+	TracingConfig
+	TLSConfig
+}
+
+// Logging returns options of the global and request loggers.
+func (c Config) Logging() logging.Options {
+	return logging.Options{Level: c.LogLevel, Format: c.LogFormat, ReportCaller: c.LogCaller}
+}
+
+// AccessLog returns options of the access log. Trusted proxies are expected to be validated.
+func (c Config) AccessLog() rest.AccessLogOptions {
+	return rest.AccessLogOptions{TrustedProxies: parsePrefixes(c.ServerTrustedProxies), ExcludePaths: c.LogAccessExcludePaths}
+}
+
+// BodyLog returns options of body logging without a redactor. Networks are expected to be validated.
+func (c Config) BodyLog() rest.BodyLogOptions {
+	return rest.BodyLogOptions{
+		Routes:           c.LogBodyRoutes,
+		DebugNetworks:    parsePrefixes(c.LogBodyDebugNetworks),
+		TrustedProxies:   parsePrefixes(c.ServerTrustedProxies),
+		MaxBytes:         c.LogBodyMaxBytes,
+		SkipContentTypes: c.LogBodySkipContentTypes,
+	}
+}
+
+// Tracing returns options of tracing without the service version.
+func (c Config) Tracing() tracing.Options {
+	return tracing.Options{
+		Exporter:     c.TracingExporter,
+		OTLPEndpoint: c.TracingOTLPEndpoint,
+		OTLPInsecure: c.TracingOTLPInsecure,
+		File:         c.TracingFile,
+		SampleRatio:  c.TracingSampleRatio,
+		ServiceName:  c.TracingServiceName,
+		Environment:  c.SentryENV,
+	}
+}
+
+// parsePrefixes parses CIDRs skipping invalid ones.
+func parsePrefixes(cidrs []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, cidr := range cidrs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		}
+	}
+	return prefixes
+}
+
+// Redactor returns the redactor of logs, query logs and Sentry events.
+func (c Config) Redactor() (*redact.Redactor, error) {
+	return redact.New(redact.Options{Headers: c.RedactHeaders, Fields: c.RedactFields, Patterns: c.RedactPatterns})
+}
+
+// DatabaseDSN returns Postgres connection string.
+func (c Config) DatabaseDSN() string {
+	return database.ConnOptions{
+		DSN:              c.DBDSN,
+		User:             c.DBUser,
+		Password:         c.DBPass,
+		Host:             c.DBHost,
+		Port:             c.DBPort,
+		Name:             c.DBName,
+		SSLMode:          c.DBSSLMode,
+		SSLRootCert:      c.DBSSLRootCert,
+		SSLCert:          c.DBSSLCert,
+		SSLKey:           c.DBSSLKey,
+		ConnectTimeout:   c.DBConnectTimeout,
+		StatementTimeout: c.DBStatementTimeout,
+		ApplicationName:  c.DBApplicationName,
+		SearchPath:       c.DBSearchPath,
+	}.ConnString()
+}
+
+// DatabasePool returns settings of the database connection pool.
+func (c Config) DatabasePool() database.PoolOptions {
+	return database.PoolOptions{
+		MaxOpenConns:    c.DBMaxOpenConns,
+		MaxIdleConns:    c.DBMaxIdleConns,
+		ConnMaxLifetime: c.DBConnMaxLifetime,
+		ConnMaxIdleTime: c.DBConnMaxIdleTime,
+	}
+}
+
+// DatabaseRetry returns options of connection retries on startup.
+func (c Config) DatabaseRetry() database.RetryOptions {
+	return database.RetryOptions{
+		Timeout:         c.DBStartupTimeout,
+		InitialInterval: c.DBRetryInitialInterval,
+		MaxInterval:     c.DBRetryMaxInterval,
+	}
+}
+
+// LogConfig is a logging configuration that complements common.Config.
+type LogConfig struct {
+	LogFormat string `envconfig:"LOG_FORMAT" default:"text" validate:"oneof=text json logfmt" desc:"Log format: text, json or logfmt. Field names follow Elastic Common Schema."`
+	LogCaller bool   `envconfig:"LOG_CALLER" default:"false" desc:"Adds the calling function and file to log entries."`
+	// LogAccessExcludePaths are probes, which flood the access log otherwise.
+	LogAccessExcludePaths []string `envconfig:"LOG_ACCESS_EXCLUDE_PATHS" default:"/status,/health/live,/health/ready,/metrics" desc:"Comma-separated paths which successful requests aren't logged to the access log."`
+	// body logging is opt-in, it's meant to debug client integrations
+	LogBodyRoutes           []string `envconfig:"LOG_BODY_ROUTES" desc:"Comma-separated route patterns, e.g. /v1/objects/{ObjectID}, which request and response bodies are added to the access log."`
+	LogBodyDebugNetworks    []string `envconfig:"LOG_BODY_DEBUG_NETWORKS" validate:"dive,cidr" desc:"Comma-separated CIDRs of clients allowed to log bodies of their requests with X-Log-Body: true header."`
+	LogBodyMaxBytes         int      `envconfig:"LOG_BODY_MAX_BYTES" default:"4096" validate:"gte=0" desc:"Maximum logged size of a request or response body, 0 disables body logging."`
+	LogBodySkipContentTypes []string `envconfig:"LOG_BODY_SKIP_CONTENT_TYPES" default:"multipart/,application/octet-stream,application/zip,image/,audio/,video/" desc:"Comma-separated content type prefixes which bodies aren't logged."`
+}
+
+// RedactConfig is a configuration of sensitive data redaction in logs, query logs and Sentry events.
+type RedactConfig struct {
+	RedactHeaders  []string `envconfig:"REDACT_HEADERS" default:"Authorization,Proxy-Authorization,Cookie,Set-Cookie,X-Api-Key" desc:"Comma-separated HTTP headers which values are redacted."`
+	RedactFields   []string `envconfig:"REDACT_FIELDS" default:"password,token,access_token,refresh_token,secret,client_secret" desc:"Comma-separated JSON field paths which values are redacted at any depth, e.g. user.email."`
+	RedactPatterns []string `envconfig:"REDACT_PATTERNS" desc:"Comma-separated regular expressions which matches are redacted, only groups when a pattern has them. Bearer tokens and passwords in URLs are always redacted."`
+}
+
+// DatabaseConfig is a configuration of Postgres connection that complements common.DbConfig.
+// DB_DSN replaces common.DbConfig, other options override its parameters when they are set.
+type DatabaseConfig struct {
+	DBDSN              string        `envconfig:"DB_DSN" secret:"true" desc:"Postgres connection string, replaces DB_USER, DB_PASS, DB_HOST, DB_PORT and DB_NAME."`
+	DBSSLMode          string        `envconfig:"DB_SSL_MODE" validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full" desc:"SSL mode: disable, allow, prefer, require, verify-ca or verify-full. It's disable when DB_DSN is empty."`
+	DBSSLRootCert      string        `envconfig:"DB_SSL_ROOT_CERT" desc:"CA bundle file to verify the Postgres server certificate."`
+	DBSSLCert          string        `envconfig:"DB_SSL_CERT" desc:"Client certificate file. It requires DB_SSL_MODE other than disable."`
+	DBSSLKey           string        `envconfig:"DB_SSL_KEY" desc:"Client private key file."`
+	DBConnectTimeout   time.Duration `envconfig:"DB_CONNECT_TIMEOUT" default:"5s" validate:"gte=0" desc:"Timeout of establishing a connection."`
+	DBStatementTimeout time.Duration `envconfig:"DB_STATEMENT_TIMEOUT" default:"0s" validate:"gte=0" desc:"Postgres statement_timeout, 0 disables it."`
+	DBApplicationName  string        `envconfig:"DB_APPLICATION_NAME" desc:"Postgres application_name."`
+	DBSearchPath       string        `envconfig:"DB_SEARCH_PATH" desc:"Schema set as Postgres search_path."`
+	DBMaxOpenConns     int           `envconfig:"DB_MAX_OPEN_CONNS" default:"0" validate:"gte=0" desc:"Maximum number of open connections, 0 is unlimited."`
+	DBMaxIdleConns     int           `envconfig:"DB_MAX_IDLE_CONNS" default:"2" validate:"gte=0" desc:"Maximum number of idle connections."`
+	DBConnMaxLifetime  time.Duration `envconfig:"DB_CONN_MAX_LIFETIME" default:"0s" validate:"gte=0" desc:"Maximum time a connection is reused, 0 is unlimited."`
+	DBConnMaxIdleTime  time.Duration `envconfig:"DB_CONN_MAX_IDLE_TIME" default:"0s" validate:"gte=0" desc:"Maximum time a connection is idle, 0 is unlimited."`
+	// startup connection retries
+	DBStartupTimeout       time.Duration `envconfig:"DB_STARTUP_TIMEOUT" default:"1m" validate:"gte=0" desc:"Time to wait for the database on startup, 0 makes a single attempt."`
+	DBRetryInitialInterval time.Duration `envconfig:"DB_RETRY_INITIAL_INTERVAL" default:"500ms" validate:"gt=0" desc:"Initial interval between connection attempts on startup, it doubles after every attempt."`
+	DBRetryMaxInterval     time.Duration `envconfig:"DB_RETRY_MAX_INTERVAL" default:"10s" validate:"gt=0" desc:"Maximum interval between connection attempts on startup."`
+}
+
+// SentrySamplingConfig is a configuration of Sentry sampling that complements common.SentryConfig.
+type SentrySamplingConfig struct {
+	SentrySampleRate       float64 `envconfig:"SENTRY_SAMPLE_RATE" default:"1" validate:"gt=0,lte=1" desc:"Share of error events sent to Sentry, greater than 0 and up to 1. Unset SENTRY_DSN to disable Sentry."`
+	SentryTracesSampleRate float64 `envconfig:"SENTRY_TRACES_SAMPLE_RATE" default:"0" validate:"gte=0,lte=1" desc:"Share of requests traced with Sentry, from 0 to 1."`
+}
+
+// AdminConfig is a configuration of admin API. The API is disabled when the token is empty.
+type AdminConfig struct {
+	AdminToken string `envconfig:"ADMIN_TOKEN" secret:"true" desc:"Bearer token of admin API. The API is disabled when the token is empty."`
+}
+
+// ServerConfig is an HTTP server configuration that complements common.Config.
+type ServerConfig struct {
+	ServerReadHeaderTimeout time.Duration `envconfig:"SERVER_READ_HEADER_TIMEOUT" default:"5s" validate:"gte=0" desc:"Time to read request headers."`
+	ServerIdleTimeout       time.Duration `envconfig:"SERVER_IDLE_TIMEOUT" default:"60s" validate:"gte=0" desc:"Time to keep idle keep-alive connections open."`
+	ServerMaxHeaderBytes    int           `envconfig:"SERVER_MAX_HEADER_BYTES" default:"1048576" validate:"gte=0" desc:"Maximum size of request headers."`
+	ServerMaxBodyBytes      int64         `envconfig:"SERVER_MAX_BODY_BYTES" default:"1048576" validate:"gte=0" desc:"Maximum size of request body; larger requests get 413 error. 0 disables the limit. Routes can set their own limits with rest.BodyLimit."`
+	ServerShutdownDelay     time.Duration `envconfig:"SERVER_SHUTDOWN_DELAY" default:"0s" validate:"gte=0" desc:"Time to keep serving with failing readiness after SIGINT/SIGTERM before shutdown starts."`
+	ServerTrustedProxies    []string      `envconfig:"SERVER_TRUSTED_PROXIES" validate:"dive,cidr" desc:"Comma-separated CIDRs of proxies, which X-Forwarded-For header is trusted to get the client IP."`
+	ServerShutdownTimeout   time.Duration `envconfig:"SERVER_SHUTDOWN_TIMEOUT" default:"30s" validate:"gt=0" desc:"Time to drain in-flight requests on shutdown."`
+}
+
+// Migration modes define what happens on startup when schema migration fails.
+const (
+	MigrationModeFail = "fail" // stop the app
+	MigrationModeWarn = "warn" // log the error and keep serving
+	MigrationModeSkip = "skip" // don't migrate on startup, e.g. when `app migrate` is a separate deploy step
+)
+
+// MigrationConfig is a schema migration configuration.
+type MigrationConfig struct {
+	MigrationMode        string        `envconfig:"MIGRATION_MODE" default:"fail" validate:"oneof=fail warn skip" desc:"Startup migration policy: fail (stop the app), warn (log and keep serving) or skip."`
+	MigrationLockTimeout time.Duration `envconfig:"MIGRATION_LOCK_TIMEOUT" default:"1m" validate:"gt=0" desc:"Time to wait for the migration lock held by another replica."`
+}
+
+// HealthConfig is a configuration of health checks.
+type HealthConfig struct {
+	HealthCacheTTL     time.Duration `envconfig:"HEALTH_CACHE_TTL" default:"1s" validate:"gte=0" desc:"Time to cache health check results."`
+	HealthCheckTimeout time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"2s" validate:"gt=0" desc:"Default timeout of a single health check."`
+}
+
+// MetricsConfig is a configuration of Prometheus metrics.
+type MetricsConfig struct {
+	MetricsEnabled bool   `envconfig:"METRICS_ENABLED" default:"true" desc:"Serves Prometheus metrics."`
+	MetricsPath    string `envconfig:"METRICS_PATH" default:"/metrics" desc:"Path of the metrics endpoint."`
+	MetricsPort    int    `envconfig:"METRICS_PORT" default:"0" validate:"gte=0,lte=65535" desc:"Port of a separate metrics server, 0 serves metrics on SERVER_PORT."`
+}
+
+// TracingConfig is a configuration of OpenTelemetry tracing.
+type TracingConfig struct {
+	TracingExporter     string  `envconfig:"TRACING_EXPORTER" default:"none" validate:"oneof=none otlp stdout file" desc:"Exporter of trace spans: none, otlp, stdout or file. Trace context is propagated with none as well."`
+	TracingOTLPEndpoint string  `envconfig:"TRACING_OTLP_ENDPOINT" desc:"host:port of an OTLP gRPC collector, OTEL_EXPORTER_OTLP_* variables are used when it's empty."`
+	TracingOTLPInsecure bool    `envconfig:"TRACING_OTLP_INSECURE" default:"false" desc:"Connects to the OTLP collector without TLS."`
+	TracingFile         string  `envconfig:"TRACING_FILE" default:"traces.json" desc:"File which spans are appended to by the file exporter, meant for local debugging."`
+	TracingSampleRatio  float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1" validate:"gte=0,lte=1" desc:"Ratio of sampled traces which sampling isn't decided by the caller with traceparent header."`
+	TracingServiceName  string  `envconfig:"TRACING_SERVICE_NAME" default:"project-template" desc:"Service name of spans."`
+}
+
+// TLSConfig is a configuration of TLS. TLS is enabled when certificate and key files are set.
+type TLSConfig struct {
+	TLSCertFile       string        `envconfig:"TLS_CERT_FILE" desc:"Server certificate file. TLS is enabled when the certificate and the key are set."`
+	TLSKeyFile        string        `envconfig:"TLS_KEY_FILE" desc:"Server private key file."`
+	TLSMinVersion     string        `envconfig:"TLS_MIN_VERSION" default:"1.2" validate:"oneof=1.0 1.1 1.2 1.3" desc:"Minimal TLS version: 1.0, 1.1, 1.2 or 1.3."`
+	TLSCipherPolicy   string        `envconfig:"TLS_CIPHER_POLICY" default:"default" validate:"oneof=default modern" desc:"TLS 1.2 cipher suites: default (Go defaults) or modern (ECDHE with AEAD only)."`
+	TLSClientCAFile   string        `envconfig:"TLS_CLIENT_CA_FILE" desc:"CA bundle to verify client certificates."`
+	TLSClientAuth     string        `envconfig:"TLS_CLIENT_AUTH" default:"none" validate:"oneof=none optional require" desc:"Client certificate verification: none, optional or require."`
+	TLSReloadInterval time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"1m" validate:"gt=0" desc:"Interval to check certificate files for changes."`
 }
@@ -0,0 +1,34 @@
+package config
+
+import "fmt"
+
+// Change is a changed configuration value. Secret values are redacted.
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Diff returns changes from old to new configuration in the order of declaration.
+func Diff(old, new Config) []Change {
+	var changes []Change
+	newFields := fields(&new)
+	for i, f := range fields(&old) {
+		oldValue, newValue := fmt.Sprint(f.value.Interface()), fmt.Sprint(newFields[i].value.Interface())
+		if oldValue == newValue {
+			continue
+		}
+		if f.secret {
+			oldValue, newValue = maskSecret(oldValue), maskSecret(newValue)
+		}
+		changes = append(changes, Change{Key: f.key, Old: oldValue, New: newValue})
+	}
+	return changes
+}
+
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	var old Config
+	old.LogLevel = "info"
+	old.DBPass = "old"
+	old.HealthCacheTTL = time.Second
+	new := old
+	new.LogLevel = "debug"
+	new.DBPass = "new"
+	new.SentryDSN = "https://key@sentry.example.com/1"
+
+	assert.Equal(t, []Change{
+		{Key: "LOG_LEVEL", Old: "info", New: "debug"},
+		{Key: "DB_PASS", Old: "******", New: "******"},
+		{Key: "SENTRY_DSN", Old: "", New: "******"},
+	}, Diff(old, new))
+	assert.Empty(t, Diff(old, old))
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// commonDescriptions describe keys of common configs, which have no `desc` tags.
+var commonDescriptions = map[string]string{
+	"LOG_LEVEL":            "Log level for logger. Possible options: trace, debug, info, warning, error, fatal and panic.",
+	"SERVER_PORT":          "Port on which app will run.",
+	"SERVER_READ_TIMEOUT":  "App read response time.",
+	"SERVER_WRITE_TIMEOUT": "App write response time.",
+	"DB_USER":              "Postgres database user.",
+	"DB_PASS":              "Postgres database password.",
+	"DB_HOST":              "Postgres database host.",
+	"DB_PORT":              "Postgres database port.",
+	"DB_NAME":              "Postgres database name.",
+	"SENTRY_DSN":           "Sentry DSN.",
+	"SENTRY_ENV":           "Sentry environment.",
+}
+
+// WriteMarkdown writes Markdown table of configuration keys with defaults and descriptions.
+func WriteMarkdown(w io.Writer) error {
+	rows := [][3]string{{"Variable", "Default", "Description"}}
+	for _, f := range fields(&Config{}) {
+		rows = append(rows, [3]string{f.key, f.def, describe(f)})
+	}
+	var widths [3]int
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	line := func(row [3]string) string {
+		pad := widths[1] - len(row[1])
+		return fmt.Sprintf("| %-*s | %s%s%s | %-*s |\n", widths[0], row[0],
+			strings.Repeat(" ", pad/2), row[1], strings.Repeat(" ", pad-pad/2), widths[2], row[2])
+	}
+	var b strings.Builder
+	b.WriteString(line(rows[0]))
+	fmt.Fprintf(&b, "|%s|:%s:|%s|\n",
+		strings.Repeat("-", widths[0]+2), strings.Repeat("-", widths[1]), strings.Repeat("-", widths[2]+2))
+	for _, row := range rows[1:] {
+		b.WriteString(line(row))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteEnvExample writes example .env file with default values.
+func WriteEnvExample(w io.Writer) error {
+	var b strings.Builder
+	for i, f := range fields(&Config{}) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s\n%s=%s\n", describe(f), f.key, f.def)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func describe(f field) string {
+	desc := f.desc
+	if f.required {
+		desc = "Required. " + desc
+	}
+	if f.secret {
+		desc += " Can be read from the file set with " + f.key + secretFileSuffix + "."
+	}
+	return desc
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	var table strings.Builder
+	require.NoError(t, WriteMarkdown(&table))
+	readme, err := os.ReadFile("../../README.md")
+	if os.IsNotExist(err) {
+		t.Skip("README.md is excluded from Docker build context")
+	}
+	require.NoError(t, err)
+	assert.Contains(t, string(readme), table.String(), "regenerate the table with `go run ./cmd/app config docs`")
+}
+
+func TestWriteEnvExample(t *testing.T) {
+	var env strings.Builder
+	require.NoError(t, WriteEnvExample(&env))
+	example, err := os.ReadFile("../../.env.example")
+	require.NoError(t, err)
+	assert.Equal(t, string(example), env.String(), "regenerate with `go run ./cmd/app config docs env > .env.example`")
+	assert.Contains(t, env.String(), "# Postgres database password. Can be read from the file set with DB_PASS_FILE.\nDB_PASS=password\n")
+}
@@ -0,0 +1,391 @@
+package config
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/tracing"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslate "github.com/go-playground/validator/v10/translations/en"
+	logs "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuration sources, from the lowest priority to the highest.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// redacted replaces secret values in configuration dumps.
+const redacted = "******"
+
+// secretKeys are secrets declared in common configs, which can't be marked with `secret` tag.
+var secretKeys = map[string]bool{"DB_PASS": true, "SENTRY_DSN": true}
+
+// validDSNSchemes are URL schemes supported by pgdriver.
+var validDSNSchemes = map[string]bool{"postgres": true, "postgresql": true, "unix": true}
+
+// secretFileSuffix makes a companion key for a secret, e.g. DB_PASS_FILE, which value is a path to the file with the secret.
+const secretFileSuffix = "_FILE"
+
+// Loader loads Config from layers, each overriding the previous one:
+// defaults, config file, environment variables and flags.
+type Loader struct {
+	// File is a YAML or TOML config file. CONFIG_FILE environment variable is used when it's empty.
+	File string
+	// Flags are values set with flags by keys.
+	Flags map[string]string
+}
+
+// Setting is a configuration value with its source.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	// File is a file the secret is read from.
+	File string
+}
+
+// field is a Config field described by struct tags.
+type field struct {
+	key      string
+	def      string
+	desc     string
+	required bool
+	secret   bool
+	value    reflect.Value
+}
+
+// RegisterFlags registers -config flag and a flag for every configuration key, e.g. -server-port for SERVER_PORT.
+func RegisterFlags(fs *flag.FlagSet) *Loader {
+	l := &Loader{Flags: make(map[string]string)}
+	fs.StringVar(&l.File, "config", "", "YAML or TOML config `file`")
+	for _, f := range fields(&Config{}) {
+		keys := []string{f.key}
+		if f.secret {
+			keys = append(keys, f.key+secretFileSuffix)
+		}
+		for _, key := range keys {
+			key := key
+			fs.Func(flagName(key), "sets "+key, func(value string) error {
+				l.Flags[key] = value
+				return nil
+			})
+		}
+	}
+	return l
+}
+
+// Load returns validated configuration with settings in the order of declaration.
+// Secrets are read from files set with companion keys, e.g. DB_PASS_FILE, in any layer.
+func (l Loader) Load() (Config, []Setting, error) {
+	var cfg Config
+	values, sources, secretFiles := make(map[string]string), make(map[string]string), make(map[string]string)
+	set := func(key, value, source string) {
+		values[key], sources[key] = value, source
+		delete(secretFiles, key)
+	}
+	setSecretFile := func(key, file, source string) error {
+		if _, ok := values[key]; ok && sources[key] == source && secretFiles[key] == "" {
+			return fmt.Errorf("both %s and %s%s are set in %s", key, key, secretFileSuffix, source)
+		}
+		secret, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", key, err)
+		}
+		values[key], sources[key], secretFiles[key] = strings.TrimRight(string(secret), "\r\n"), source, file
+		return nil
+	}
+
+	file := l.File
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	var fileValues map[string]string
+	if file != "" {
+		var err error
+		if fileValues, err = readFile(file); err != nil {
+			return cfg, nil, err
+		}
+	}
+
+	cfgFields := fields(&cfg)
+	known := make(map[string]bool, len(cfgFields))
+	layers := []struct {
+		source string
+		lookup func(key string) (string, bool)
+	}{
+		{SourceFile, lookupMap(fileValues)},
+		{SourceEnv, os.LookupEnv},
+		{SourceFlag, lookupMap(l.Flags)},
+	}
+	for _, f := range cfgFields {
+		known[f.key] = true
+		if f.secret {
+			known[f.key+secretFileSuffix] = true
+		}
+		set(f.key, f.def, SourceDefault)
+		for _, layer := range layers {
+			if value, ok := layer.lookup(f.key); ok {
+				set(f.key, value, layer.source)
+			}
+			if !f.secret {
+				continue
+			}
+			if file, ok := layer.lookup(f.key + secretFileSuffix); ok {
+				if err := setSecretFile(f.key, file, layer.source); err != nil {
+					return cfg, nil, err
+				}
+			}
+		}
+		if f.required && values[f.key] == "" {
+			return cfg, nil, fmt.Errorf("%s is required", f.key)
+		}
+		if err := setValue(f.value, values[f.key]); err != nil && f.secret {
+			return cfg, nil, fmt.Errorf("invalid %s value from %s", f.key, sources[f.key])
+		} else if err != nil {
+			return cfg, nil, fmt.Errorf("invalid %s value %q from %s: %w", f.key, values[f.key], sources[f.key], err)
+		}
+	}
+	for key := range fileValues {
+		if !known[key] {
+			return cfg, nil, fmt.Errorf("unknown key %s in config file %s", key, file)
+		}
+	}
+	if err := Validate(cfg); err != nil {
+		return cfg, nil, err
+	}
+
+	settings := make([]Setting, 0, len(cfgFields))
+	for _, f := range cfgFields {
+		value := values[f.key]
+		if f.secret {
+			value = maskSecret(value)
+		}
+		settings = append(settings, Setting{Key: f.key, Value: value, Source: sources[f.key], File: secretFiles[f.key]})
+	}
+	return cfg, settings, nil
+}
+
+// Validate checks configuration according to `validate` tags.
+func Validate(cfg Config) error {
+	en := en.New()
+	trans, _ := ut.New(en, en).GetTranslator("en")
+	valid := validator.New()
+	valid.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		return fld.Tag.Get("envconfig")
+	})
+	_ = entranslate.RegisterDefaultTranslations(valid, trans)
+
+	var msgs []string
+	if err := valid.Struct(cfg); err != nil {
+		var fieldsErr validator.ValidationErrors
+		if !errors.As(err, &fieldsErr) {
+			return err
+		}
+		for _, fErr := range fieldsErr {
+			msgs = append(msgs, fErr.Translate(trans))
+		}
+	}
+	// common configs have no validation tags, and rules between fields read better as code
+	if _, err := logs.ParseLevel(cfg.LogLevel); err != nil {
+		msgs = append(msgs, err.Error())
+	}
+	if cfg.ServerPort < 1 || cfg.ServerPort > 65535 {
+		msgs = append(msgs, "SERVER_PORT must be between 1 and 65535")
+	}
+	if !strings.HasPrefix(cfg.MetricsPath, "/") {
+		msgs = append(msgs, "METRICS_PATH must start with /")
+	}
+	if cfg.MetricsEnabled && cfg.MetricsPort == cfg.ServerPort {
+		msgs = append(msgs, "METRICS_PORT must differ from SERVER_PORT")
+	}
+	if u, err := url.Parse(cfg.DBDSN); cfg.DBDSN != "" && (err != nil || !validDSNSchemes[u.Scheme]) {
+		msgs = append(msgs, "DB_DSN must be a postgres://, postgresql:// or unix:// URL")
+	}
+	if u, err := url.Parse(cfg.DatabaseDSN()); (cfg.DBSSLCert != "" || cfg.DBSSLKey != "") && err == nil && u.Query().Get("sslmode") == "disable" {
+		msgs = append(msgs, "DB_SSL_CERT and DB_SSL_KEY require DB_SSL_MODE other than disable")
+	}
+	if cfg.DBRetryMaxInterval < cfg.DBRetryInitialInterval {
+		msgs = append(msgs, "DB_RETRY_MAX_INTERVAL must be greater than or equal to DB_RETRY_INITIAL_INTERVAL")
+	}
+	for _, pattern := range cfg.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			msgs = append(msgs, fmt.Sprintf("REDACT_PATTERNS contains invalid pattern %q", pattern))
+		}
+	}
+	if cfg.TracingExporter == tracing.ExporterFile && cfg.TracingFile == "" {
+		msgs = append(msgs, "TRACING_FILE must be set for the file exporter")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		msgs = append(msgs, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// fields returns fields of cfg and its embedded structs.
+func fields(cfg any) []field {
+	var result []field
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			result = append(result, fields(v.Field(i).Addr().Interface())...)
+			continue
+		}
+		key := structField.Tag.Get("envconfig")
+		if key == "" {
+			continue
+		}
+		desc := structField.Tag.Get("desc")
+		if desc == "" {
+			desc = commonDescriptions[key]
+		}
+		result = append(result, field{
+			key:      key,
+			def:      structField.Tag.Get("default"),
+			desc:     desc,
+			required: structField.Tag.Get("required") == "true",
+			secret:   structField.Tag.Get("secret") == "true" || secretKeys[key],
+			value:    v.Field(i),
+		})
+	}
+	return result
+}
+
+// setValue parses s into v according to its type. Slices are comma-separated.
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		if s == "" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			s = "false"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		var items []string
+		if s != "" {
+			items = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// readFile reads flat YAML or TOML config file. Keys are case-insensitive configuration keys, e.g. server_port.
+func readFile(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read config file: %w", err)
+	}
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unknown config file format %s", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't parse config file %s: %w", file, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value := value.(type) {
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[strings.ToUpper(key)] = strings.Join(items, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("config file %s: nested key %s isn't supported", file, key)
+		default:
+			values[strings.ToUpper(key)] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+func lookupMap(values map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
+
+func settingsByKey(settings []Setting) map[string]Setting {
+	result := make(map[string]Setting, len(settings))
+	for _, s := range settings {
+		result[s.Key] = s
+	}
+	return result
+}
+
+func TestLoader_Load(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		file := writeFile(t, "app.yaml", `
+server_port: 9000
+server_read_timeout: 20s
+DB_HOST: file-host
+DB_NAME: file-db
+db_pass: file-secret
+`)
+		t.Setenv("DB_HOST", "env-host")
+		t.Setenv("DB_NAME", "env-db")
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		loader := RegisterFlags(fs)
+		require.NoError(t, fs.Parse([]string{"-config", file, "-db-name", "flag-db"}))
+
+		cfg, settings, err := loader.Load()
+		require.NoError(t, err)
+		assert.Equal(t, 9000, cfg.ServerPort)
+		assert.Equal(t, 20*time.Second, cfg.ServerReadTimeout)
+		assert.Equal(t, 15*time.Second, cfg.ServerWriteTimeout)
+		assert.Equal(t, "env-host", cfg.DBHost)
+		assert.Equal(t, "flag-db", cfg.DBName)
+		assert.Equal(t, "file-secret", cfg.DBPass)
+
+		byKey := settingsByKey(settings)
+		assert.Equal(t, Setting{Key: "SERVER_PORT", Value: "9000", Source: SourceFile}, byKey["SERVER_PORT"])
+		assert.Equal(t, Setting{Key: "SERVER_WRITE_TIMEOUT", Value: "15s", Source: SourceDefault}, byKey["SERVER_WRITE_TIMEOUT"])
+		assert.Equal(t, Setting{Key: "DB_HOST", Value: "env-host", Source: SourceEnv}, byKey["DB_HOST"])
+		assert.Equal(t, Setting{Key: "DB_NAME", Value: "flag-db", Source: SourceFlag}, byKey["DB_NAME"])
+		assert.Equal(t, Setting{Key: "DB_PASS", Value: "******", Source: SourceFile}, byKey["DB_PASS"])
+		assert.Equal(t, Setting{Key: "SENTRY_DSN", Value: "", Source: SourceDefault}, byKey["SENTRY_DSN"])
+	})
+	t.Run("TOML", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", writeFile(t, "app.toml", `
+migration_mode = "skip"
+health_cache_ttl = "5s"
+`))
+		cfg, _, err := Loader{}.Load()
+		require.NoError(t, err)
+		assert.Equal(t, MigrationModeSkip, cfg.MigrationMode)
+		assert.Equal(t, 5*time.Second, cfg.HealthCacheTTL)
+	})
+	t.Run("secret files", func(t *testing.T) {
+		dsnFile := writeFile(t, "sentry_dsn", "https://key@sentry.example.com/1\n")
+		passFile := writeFile(t, "db_pass", "rotated\n")
+		t.Setenv("DB_PASS", "plain")
+		t.Setenv("CONFIG_FILE", writeFile(t, "app.yaml", "sentry_dsn_file: "+dsnFile))
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		loader := RegisterFlags(fs)
+		require.NoError(t, fs.Parse([]string{"-db-pass-file", passFile}))
+
+		cfg, settings, err := loader.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "rotated", cfg.DBPass)
+		assert.Equal(t, "https://key@sentry.example.com/1", cfg.SentryDSN)
+
+		byKey := settingsByKey(settings)
+		assert.Equal(t, Setting{Key: "DB_PASS", Value: "******", Source: SourceFlag, File: passFile}, byKey["DB_PASS"])
+		assert.Equal(t, Setting{Key: "SENTRY_DSN", Value: "******", Source: SourceFile, File: dsnFile}, byKey["SENTRY_DSN"])
+	})
+	t.Run("metrics port is ignored when metrics are disabled", func(t *testing.T) {
+		t.Setenv("METRICS_ENABLED", "false")
+		t.Setenv("METRICS_PORT", "8080")
+		t.Setenv("SERVER_PORT", "8080")
+
+		cfg, _, err := Loader{}.Load()
+		require.NoError(t, err)
+		assert.False(t, cfg.MetricsEnabled)
+	})
+	t.Run("Error", func(t *testing.T) {
+		tests := []struct {
+			name string
+			file string
+			env  map[string]string
+			err  string
+		}{
+			{
+				name: "unknown key",
+				file: "unknown_key: 1",
+				err:  "unknown key UNKNOWN_KEY in config file",
+			},
+			{
+				name: "invalid value",
+				env:  map[string]string{"SERVER_PORT": "port"},
+				err:  `invalid SERVER_PORT value "port" from env`,
+			},
+			{
+				name: "secret and secret file",
+				env:  map[string]string{"DB_PASS": "plain", "DB_PASS_FILE": "db_pass"},
+				err:  "both DB_PASS and DB_PASS_FILE are set in env",
+			},
+			{
+				name: "missing secret file",
+				env:  map[string]string{"DB_PASS_FILE": "missing"},
+				err:  "can't read DB_PASS: open missing: no such file or directory",
+			},
+			{
+				name: "invalid DSN",
+				env:  map[string]string{"DB_DSN": "mysql://app:secret@db/app"},
+				err:  "invalid configuration: DB_DSN must be a postgres://, postgresql:// or unix:// URL",
+			},
+			{
+				name: "metrics",
+				env:  map[string]string{"METRICS_PATH": "metrics", "METRICS_PORT": "8080", "SERVER_PORT": "8080"},
+				err:  "invalid configuration: METRICS_PATH must start with /; METRICS_PORT must differ from SERVER_PORT",
+			},
+			{
+				name: "invalid redaction pattern",
+				env:  map[string]string{"REDACT_PATTERNS": "token=(\\w+,x"},
+				err:  `invalid configuration: REDACT_PATTERNS contains invalid pattern "token=(\\w+"`,
+			},
+			{
+				name: "client certificate without SSL",
+				env:  map[string]string{"DB_SSL_CERT": "tls.crt", "DB_SSL_KEY": "tls.key"},
+				err:  "invalid configuration: DB_SSL_CERT and DB_SSL_KEY require DB_SSL_MODE other than disable",
+			},
+			{
+				name: "zero sentry sample rate",
+				env:  map[string]string{"SENTRY_SAMPLE_RATE": "0"},
+				err:  "invalid configuration: SENTRY_SAMPLE_RATE must be greater than 0",
+			},
+			{
+				name: "validation",
+				env:  map[string]string{"MIGRATION_MODE": "always", "SERVER_PORT": "70000", "TLS_CERT_FILE": "tls.crt"},
+				err: "invalid configuration: MIGRATION_MODE must be one of [fail warn skip]; " +
+					"SERVER_PORT must be between 1 and 65535; TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				for key, value := range tt.env {
+					t.Setenv(key, value)
+				}
+				loader := Loader{}
+				if tt.file != "" {
+					loader.File = writeFile(t, "app.yml", tt.file)
+				}
+				_, _, err := loader.Load()
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+			})
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/health"
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	logs "github.com/sirupsen/logrus"
+	"io/fs"
+	"net/http"
+)
+
+// Module is a feature module mounted into the API router.
+type Module interface {
+	// Name is a unique module name used in logs, health checks and migrations table name.
+	Name() string
+	// Path is a mount path relative to the API prefix, e.g. /objects.
+	Path() string
+	// Router handles module requests.
+	Router() http.Handler
+}
+
+// MigrationsProvider is implemented by modules owning schema migrations.
+// They are tracked in a separate migrations table, see MigrationsTable.
+type MigrationsProvider interface {
+	Migrations() fs.FS
+}
+
+// HealthChecker is implemented by modules with own dependencies to check for readiness.
+type HealthChecker interface {
+	HealthChecks() []health.Check
+}
+
+// Starter is implemented by modules with background work started before the app serves requests.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by modules releasing resources after the app stops serving requests.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// MigrationsTable returns table name to track module migrations.
+func MigrationsTable(m Module) string {
+	return "schema_migrations_" + m.Name()
+}
+
+// MountModules mounts module routers into the router.
+func MountModules(r chi.Router, modules []Module) {
+	for _, m := range modules {
+		r.Mount(m.Path(), m.Router())
+	}
+}
+
+// RegisterHealthChecks registers module health checks prefixed with the module name.
+func RegisterHealthChecks(registry *health.Registry, modules []Module) {
+	for _, m := range modules {
+		checker, ok := m.(HealthChecker)
+		if !ok {
+			continue
+		}
+		for _, check := range checker.HealthChecks() {
+			check.Name = m.Name() + "." + check.Name
+			registry.Register(check)
+		}
+	}
+}
+
+// StartModules starts modules in order. On the first failure the modules already started are stopped
+// in reverse order and the start error is returned.
+func StartModules(ctx context.Context, modules []Module) error {
+	for i, m := range modules {
+		if starter, ok := m.(Starter); ok {
+			if err := starter.Start(ctx); err != nil {
+				if stopErr := StopModules(ctx, modules[:i]); stopErr != nil {
+					logs.Errorf("Can't stop started modules; error: %v", stopErr)
+				}
+				return fmt.Errorf("can't start %s module: %w", m.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// StopModules stops modules in reverse order. All modules are stopped even if some of them fail,
+// the first error is returned.
+func StopModules(ctx context.Context, modules []Module) error {
+	var firstErr error
+	for i := len(modules) - 1; i >= 0; i-- {
+		m := modules[i]
+		if stopper, ok := m.(Stopper); ok {
+			if err := stopper.Stop(ctx); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("can't stop %s module: %w", m.Name(), err)
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/health"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testModule is a module with all optional interfaces implemented.
+type testModule struct {
+	name     string
+	calls    *[]string
+	startErr error
+	stopErr  error
+}
+
+func (m testModule) Name() string {
+	return m.name
+}
+
+func (m testModule) Path() string {
+	return "/" + m.name
+}
+
+func (m testModule) Router() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(m.name))
+	})
+}
+
+func (m testModule) HealthChecks() []health.Check {
+	return []health.Check{{Name: "upstream", Check: func(context.Context) error { return nil }}}
+}
+
+func (m testModule) Start(context.Context) error {
+	*m.calls = append(*m.calls, "start "+m.name)
+	return m.startErr
+}
+
+func (m testModule) Stop(context.Context) error {
+	*m.calls = append(*m.calls, "stop "+m.name)
+	return m.stopErr
+}
+
+func TestMountModules(t *testing.T) {
+	var calls []string
+	router := chi.NewRouter()
+	MountModules(router, []Module{testModule{name: "first", calls: &calls}, testModule{name: "second", calls: &calls}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/second", nil))
+
+	assert.Equal(t, "second", w.Body.String())
+}
+
+func TestStartStopModules(t *testing.T) {
+	var calls []string
+	modules := []Module{
+		testModule{name: "first", calls: &calls, stopErr: errors.New("some error")},
+		testModule{name: "second", calls: &calls},
+	}
+
+	require.NoError(t, StartModules(context.Background(), modules))
+	err := StopModules(context.Background(), modules)
+
+	require.EqualError(t, err, "can't stop first module: some error")
+	assert.Equal(t, []string{"start first", "start second", "stop second", "stop first"}, calls)
+}
+
+func TestStartModules_Failure(t *testing.T) {
+	var calls []string
+	modules := []Module{
+		testModule{name: "first", calls: &calls},
+		testModule{name: "second", calls: &calls},
+		testModule{name: "third", calls: &calls, startErr: errors.New("some error")},
+		testModule{name: "fourth", calls: &calls},
+	}
+
+	err := StartModules(context.Background(), modules)
+
+	require.EqualError(t, err, "can't start third module: some error")
+	assert.Equal(t, []string{"start first", "start second", "start third", "stop second", "stop first"}, calls)
+}
+
+func TestRegisterHealthChecks(t *testing.T) {
+	registry := health.NewRegistry(0, time.Second)
+	RegisterHealthChecks(registry, []Module{testModule{name: "first"}})
+
+	report := registry.Run(context.Background())
+
+	assert.Contains(t, report.Checks, "first.upstream")
+}
@@ -0,0 +1,34 @@
+package object_module
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/internal/repositories"
+	"bitbucket.org/creativeadvtech/project-template/pkg/database"
+	"net/http"
+)
+
+// Module is a feature module that manages objects.
+type Module struct {
+	rest *Rest
+}
+
+// New returns objects module backed by the database.
+func New(db *database.DB) *Module {
+	return NewWithRepository(repositories.NewObjectRepository(db))
+}
+
+// NewWithRepository returns objects module backed by the repository.
+func NewWithRepository(repo Repository) *Module {
+	return &Module{rest: NewRest(NewModule(repo))}
+}
+
+func (m *Module) Name() string {
+	return "objects"
+}
+
+func (m *Module) Path() string {
+	return "/objects"
+}
+
+func (m *Module) Router() http.Handler {
+	return m.rest
+}
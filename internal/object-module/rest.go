@@ -20,6 +20,9 @@ type Service interface {
 	Delete(ctx context.Context, id common.UUID) error
 }
 
+// maxObjectBodyBytes limits bodies of create and update requests.
+const maxObjectBodyBytes = 64 << 10
+
 type createObject struct {
 	Data string `json:"data,omitempty" mod:"trim"`
 }
@@ -41,8 +44,9 @@ func NewRest(svc Service) *Rest {
 
 	res.Get("/", rest.APIHandlerFunc(res.list))
 	res.Get("/{ObjectID}", rest.APIHandlerFunc(res.get))
-	res.Post("/", rest.APIHandlerFunc(res.create))
-	res.Put("/{ObjectID}", rest.APIHandlerFunc(res.update))
+	// objects are small, so their bodies are limited below SERVER_MAX_BODY_BYTES
+	res.With(rest.BodyLimit(maxObjectBodyBytes)).Post("/", rest.APIHandlerFunc(res.create))
+	res.With(rest.BodyLimit(maxObjectBodyBytes)).Put("/{ObjectID}", rest.APIHandlerFunc(res.update))
 	res.Delete("/{ObjectID}", rest.APIHandlerFunc(res.delete))
 
 	return res
@@ -86,8 +90,8 @@ func (api Rest) get(w http.ResponseWriter, r *http.Request) error {
 
 func (api Rest) create(w http.ResponseWriter, r *http.Request) error {
 	cObject := &createObject{}
-	if err := rest.ReadBody(r, cObject); err != nil {
-		return rest.BadRequestErrorf("can't parse body").WithError(err)
+	if err := readBody(r, cObject); err != nil {
+		return err
 	}
 
 	if err := api.PrepareParams(r.Context(), cObject); err != nil {
@@ -108,8 +112,8 @@ func (api Rest) update(w http.ResponseWriter, r *http.Request) error {
 	uObject := &updateObject{}
 	var id common.UUID
 
-	if err := rest.ReadBody(r, uObject); err != nil {
-		return rest.BadRequestErrorf("can't parse body").WithError(err)
+	if err := readBody(r, uObject); err != nil {
+		return err
 	}
 
 	if err := common.ParseUUID(rest.ReadPathParam(r, "ObjectID"), &id); err != nil {
@@ -145,3 +149,15 @@ func (api Rest) delete(w http.ResponseWriter, r *http.Request) error {
 	}
 	return rest.WriteOK(w, rest.NewHTTPError(http.StatusOK, "successfully deleted"))
 }
+
+// readBody reads JSON body of create and update requests. Too large bodies get 413 error, other errors get 400.
+func readBody(r *http.Request, object any) error {
+	err := rest.ReadBody(r, object)
+	var httpErr *rest.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code == http.StatusRequestEntityTooLarge {
+		return err
+	} else if err != nil {
+		return rest.BadRequestErrorf("can't parse body").WithError(err)
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -177,6 +179,25 @@ func TestRest_create(t *testing.T) {
 		err := res.create(w, r)
 		require.EqualError(t, err, "some error")
 	})
+
+	t.Run("Invalid body", func(t *testing.T) {
+		w, r := testutils.NewTestRequest(
+			testutils.WithBody([]byte(`{"data":`)),
+		)
+
+		err := res.create(w, r)
+		require.EqualError(t, err, "400: can't parse body")
+	})
+
+	t.Run("Too large body", func(t *testing.T) {
+		srv.Mock = mock.Mock{}
+		body := `{"data":"` + strings.Repeat("x", maxObjectBodyBytes) + `"}`
+
+		w := httptest.NewRecorder()
+		res.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		srv.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
 }
 
 func TestRest_update(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"bitbucket.org/creativeadvtech/project-template/pkg/common"
 	"bitbucket.org/creativeadvtech/project-template/pkg/database"
 	"bitbucket.org/creativeadvtech/project-template/pkg/errs"
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
 	"context"
 	"errors"
 	"github.com/jinzhu/copier"
@@ -70,5 +71,8 @@ func (m ObjectService) Delete(ctx context.Context, id common.UUID) error {
 	if errors.Is(err, database.ErrNotFound) {
 		return errs.New[errs.NotFound]("object not found")
 	}
+	if err == nil {
+		logging.FromContext(ctx).WithField("object.id", id).Info("Object is deleted.")
+	}
 	return err
 }
@@ -6,13 +6,17 @@ import (
 )
 
 type statusResponse struct {
-	Status  string `json:"status"`
-	Version string `json:"version"`
+	Status string `json:"status"`
+	BuildInfo
 }
 
-// Status returns status of the service
-func Status(version string) func(w http.ResponseWriter, _ *http.Request) error {
+// Status returns status and build information of the service.
+// It responds with 503 once ready reports false, e.g. during shutdown.
+func Status(build BuildInfo, ready func() bool) func(w http.ResponseWriter, _ *http.Request) error {
 	return func(w http.ResponseWriter, _ *http.Request) error {
-		return rest.WriteOK(w, statusResponse{Status: "ok", Version: version})
+		if !ready() {
+			return rest.WriteJSON(w, statusResponse{Status: "unavailable", BuildInfo: build}, http.StatusServiceUnavailable)
+		}
+		return rest.WriteOK(w, statusResponse{Status: "ok", BuildInfo: build})
 	}
 }
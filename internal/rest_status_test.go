@@ -2,19 +2,50 @@ package internal
 
 import (
 	"bitbucket.org/creativeadvtech/project-template/pkg/testutils"
-	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
 )
 
+func testBuildInfo() BuildInfo {
+	return BuildInfo{
+		Version:   "0.0.0",
+		GitCommit: "3f2c9a1e5b7d4c6a8e0f1b2d3c4e5f6a7b8c9d0e",
+		Dirty:     true,
+		BuildTime: "2022-07-02T00:00:00Z",
+		GoVersion: "go1.19",
+	}
+}
+
 func TestStatus_API(t *testing.T) {
-	w, r := testutils.NewTestRequest()
-	err := Status("0.0.0")(w, r)
-	require.NoError(t, err)
-	expected, err := json.Marshal(statusResponse{Status: "ok", Version: "0.0.0"})
-	require.NoError(t, err)
-	assert.Equal(t, http.StatusOK, w.Code)
-	assert.JSONEq(t, string(expected), w.Body.String())
+	t.Run("OK", func(t *testing.T) {
+		w, r := testutils.NewTestRequest()
+		err := Status(testBuildInfo(), func() bool { return true })(w, r)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"status": "ok",
+			"version": "0.0.0",
+			"git_commit": "3f2c9a1e5b7d4c6a8e0f1b2d3c4e5f6a7b8c9d0e",
+			"dirty": true,
+			"build_time": "2022-07-02T00:00:00Z",
+			"go_version": "go1.19"
+		}`, w.Body.String())
+	})
+
+	t.Run("Not ready", func(t *testing.T) {
+		w, r := testutils.NewTestRequest()
+		err := Status(BuildInfo{Version: "0.0.0", GoVersion: "go1.19"}, func() bool { return false })(w, r)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status": "unavailable", "version": "0.0.0", "dirty": false, "go_version": "go1.19"}`, w.Body.String())
+	})
+}
+
+func TestBuildInfo_Release(t *testing.T) {
+	assert.Equal(t, "0.0.0+3f2c9a1e5b7d.dirty", testBuildInfo().Release())
+	assert.Equal(t, "0.0.0+3f2c9a1e5b7d", BuildInfo{Version: "0.0.0", GitCommit: "3f2c9a1e5b7d4c6a8e0f1b2d3c4e5f6a7b8c9d0e"}.Release())
+	assert.Equal(t, "0.0.0+3f2c9a1", BuildInfo{Version: "0.0.0", GitCommit: "3f2c9a1"}.Release())
+	assert.Equal(t, "0.0.0", BuildInfo{Version: "0.0.0"}.Release())
 }
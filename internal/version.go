@@ -1,5 +1,95 @@
 package internal
 
-const (
-	AppVersion = "0.0.1"
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
 )
+
+// Build metadata is set at build time, e.g.:
+//
+//	go build -ldflags "-X bitbucket.org/creativeadvtech/project-template/internal.GitCommit=$(git rev-parse HEAD)" ./cmd/app
+//
+// When it's not set, the module version and VCS information stamped by the go tool are used.
+var (
+	AppVersion = ""
+	GitCommit  = ""
+	GitDirty   = "" // "true" or "false"
+	BuildTime  = "" // RFC 3339
+)
+
+// defaultVersion is used when the version is set neither with ldflags nor by the go tool.
+const defaultVersion = "0.0.1"
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit,omitempty"`
+	Dirty     bool   `json:"dirty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// GetBuildInfo returns build metadata from ldflags falling back to the build info embedded by the go tool.
+func GetBuildInfo() BuildInfo {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		buildInfo = nil
+	}
+	return newBuildInfo(buildInfo)
+}
+
+// newBuildInfo returns build metadata from ldflags falling back to buildInfo when it's not nil.
+func newBuildInfo(buildInfo *debug.BuildInfo) BuildInfo {
+	info := BuildInfo{
+		Version:   AppVersion,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	info.Dirty, _ = strconv.ParseBool(GitDirty)
+	if buildInfo != nil {
+		// go install module@version stamps the module version, local builds have (devel)
+		if info.Version == "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+		var vcsDirty string
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.GitCommit == "" {
+					info.GitCommit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			case "vcs.modified":
+				vcsDirty = setting.Value
+			}
+		}
+		if GitDirty == "" {
+			info.Dirty, _ = strconv.ParseBool(vcsDirty)
+		}
+	}
+	if info.Version == "" {
+		info.Version = defaultVersion
+	}
+	return info
+}
+
+// Release returns release name for error tracking, e.g. 0.0.1+3f2c9a1e5b7d.
+func (b BuildInfo) Release() string {
+	if b.GitCommit == "" {
+		return b.Version
+	}
+	commit := b.GitCommit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	release := b.Version + "+" + commit
+	if b.Dirty {
+		release += ".dirty"
+	}
+	return release
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// setBuildVars sets build metadata as ldflags would do for the test.
+func setBuildVars(t *testing.T, version, commit, dirty, buildTime string) {
+	prevVersion, prevCommit, prevDirty, prevBuildTime := AppVersion, GitCommit, GitDirty, BuildTime
+	AppVersion, GitCommit, GitDirty, BuildTime = version, commit, dirty, buildTime
+	t.Cleanup(func() {
+		AppVersion, GitCommit, GitDirty, BuildTime = prevVersion, prevCommit, prevDirty, prevBuildTime
+	})
+}
+
+func TestNewBuildInfo(t *testing.T) {
+	stamped := &debug.BuildInfo{
+		Main: debug.Module{Path: "bitbucket.org/creativeadvtech/project-template", Version: "v1.2.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "3f2c9a1e5b7d4c6a8e0f1b2d3c4e5f6a7b8c9d0e"},
+			{Key: "vcs.time", Value: "2022-07-02T00:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	t.Run("ldflags take precedence", func(t *testing.T) {
+		setBuildVars(t, "1.3.0", "0123456789abcdef0123456789abcdef01234567", "false", "2023-01-02T03:04:05Z")
+
+		assert.Equal(t, BuildInfo{
+			Version:   "1.3.0",
+			GitCommit: "0123456789abcdef0123456789abcdef01234567",
+			BuildTime: "2023-01-02T03:04:05Z",
+			GoVersion: runtime.Version(),
+		}, newBuildInfo(stamped))
+	})
+
+	t.Run("go tool build info", func(t *testing.T) {
+		setBuildVars(t, "", "", "", "")
+
+		assert.Equal(t, BuildInfo{
+			Version:   "v1.2.0",
+			GitCommit: "3f2c9a1e5b7d4c6a8e0f1b2d3c4e5f6a7b8c9d0e",
+			Dirty:     true,
+			BuildTime: "2022-07-02T00:00:00Z",
+			GoVersion: runtime.Version(),
+		}, newBuildInfo(stamped))
+	})
+
+	t.Run("default version", func(t *testing.T) {
+		setBuildVars(t, "", "", "", "")
+
+		assert.Equal(t, defaultVersion, newBuildInfo(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}).Version)
+		assert.Equal(t, BuildInfo{Version: defaultVersion, GoVersion: runtime.Version()}, newBuildInfo(nil))
+	})
+}
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS contains SQL migration files embedded into the binary.
+//
+//go:embed *.sql
+var FS embed.FS
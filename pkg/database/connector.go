@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ConnOptions are Postgres connection options. The DSN is built from User, Password, Host, Port and Name
+// unless DSN is set. Other options override DSN parameters when they are set.
+type ConnOptions struct {
+	DSN      string
+	User     string
+	Password string
+	Host     string
+	Port     string
+	Name     string
+
+	SSLMode     string // disable, allow, prefer, require, verify-ca or verify-full
+	SSLRootCert string // CA bundle file to verify the server certificate
+	SSLCert     string // client certificate file
+	SSLKey      string // client private key file
+
+	ConnectTimeout   time.Duration
+	StatementTimeout time.Duration
+	ApplicationName  string
+	SearchPath       string // schema set as search_path of every connection
+}
+
+// ConnString returns DSN with all options as parameters.
+// Client certificate parameters sslcert and sslkey are supported by Connector, but not by pgdriver.
+func (o ConnOptions) ConnString() string {
+	u := &url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(o.User, o.Password),
+		Host:   net.JoinHostPort(o.Host, o.Port),
+		Path:   "/" + o.Name,
+	}
+	query := url.Values{"sslmode": {"disable"}}
+	if o.DSN != "" {
+		var err error
+		if u, err = url.Parse(o.DSN); err != nil {
+			// pgdriver reports the invalid DSN
+			return o.DSN
+		}
+		query = u.Query()
+	}
+
+	params := []struct{ name, value string }{
+		{"sslmode", o.SSLMode},
+		{"sslrootcert", o.SSLRootCert},
+		{"sslcert", o.SSLCert},
+		{"sslkey", o.SSLKey},
+		{"application_name", o.ApplicationName},
+		{"search_path", o.SearchPath},
+	}
+	if o.ConnectTimeout != 0 {
+		params = append(params, struct{ name, value string }{"connect_timeout", o.ConnectTimeout.String()})
+	}
+	if o.StatementTimeout != 0 {
+		params = append(params, struct{ name, value string }{"statement_timeout", strconv.FormatInt(o.StatementTimeout.Milliseconds(), 10)})
+	}
+	for _, param := range params {
+		if param.value != "" {
+			query.Set(param.name, param.value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// PoolOptions are settings of the connection pool. Zero values keep database/sql defaults.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+func (o PoolOptions) apply(db *sql.DB) {
+	if o.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(o.MaxOpenConns)
+	}
+	if o.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(o.MaxIdleConns)
+	}
+	if o.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(o.ConnMaxLifetime)
+	}
+	if o.ConnMaxIdleTime != 0 {
+		db.SetConnMaxIdleTime(o.ConnMaxIdleTime)
+	}
+}
+
+// Connector is a Postgres connector with replaceable DSN, e.g. to use rotated credentials.
+// Open connections are kept, new connections use the current DSN.
+type Connector struct {
+	current atomic.Pointer[pgdriver.Connector]
+}
+
+// NewConnector returns connector for dsn.
+func NewConnector(dsn string) (*Connector, error) {
+	c := &Connector{}
+	if err := c.SetDSN(dsn); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetDSN replaces DSN for new connections.
+func (c *Connector) SetDSN(dsn string) error {
+	connector, err := newPGConnector(dsn)
+	if err != nil {
+		return err
+	}
+	c.current.Store(connector)
+	return nil
+}
+
+// Connect opens connection with the current DSN.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.current.Load().Connect(ctx)
+}
+
+// Driver returns the underlying driver.
+func (c *Connector) Driver() driver.Driver {
+	return c.current.Load().Driver()
+}
+
+// newPGConnector returns pgdriver connector with client certificate set with sslcert and sslkey DSN parameters.
+// The client certificate requires SSL, it's an error to set it with sslmode=disable.
+// Errors don't include the DSN as it may contain the password.
+func newPGConnector(dsn string) (connector *pgdriver.Connector, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.New("invalid database DSN")
+	}
+	query := u.Query()
+	certFile, keyFile := query.Get("sslcert"), query.Get("sslkey")
+	query.Del("sslcert")
+	query.Del("sslkey")
+	u.RawQuery = query.Encode()
+
+	opts := []pgdriver.Option{pgdriver.WithDSN(u.String())}
+	if (certFile != "" || keyFile != "") && query.Get("sslmode") == "disable" {
+		return nil, errors.New("database client certificate is set, but SSL is disabled with sslmode=disable")
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load database client certificate: %w", err)
+		}
+		opts = append(opts, func(cfg *pgdriver.Config) {
+			if cfg.TLSConfig != nil {
+				cfg.TLSConfig.Certificates = []tls.Certificate{cert}
+			}
+		})
+	}
+
+	// pgdriver panics on invalid DSN parameters
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid database DSN: %v", r)
+		}
+	}()
+	return pgdriver.NewConnector(opts...), nil
+}
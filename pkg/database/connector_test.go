@@ -0,0 +1,69 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnOptions_ConnString(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ConnOptions
+		dsn  string
+	}{
+		{
+			name: "discrete fields",
+			opts: ConnOptions{User: "app", Password: "p@ss/word", Host: "db", Port: "5432", Name: "app"},
+			dsn:  "postgresql://app:p%40ss%2Fword@db:5432/app?sslmode=disable",
+		},
+		{
+			name: "options",
+			opts: ConnOptions{
+				User: "app", Host: "db", Port: "5432", Name: "app",
+				SSLMode: "verify-full", SSLRootCert: "/certs/ca.crt", SSLCert: "/certs/tls.crt", SSLKey: "/certs/tls.key",
+				ConnectTimeout: 3 * time.Second, StatementTimeout: 30 * time.Second,
+				ApplicationName: "api", SearchPath: "tenant",
+			},
+			dsn: "postgresql://app:@db:5432/app?application_name=api&connect_timeout=3s&search_path=tenant" +
+				"&sslcert=%2Fcerts%2Ftls.crt&sslkey=%2Fcerts%2Ftls.key&sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.crt" +
+				"&statement_timeout=30000",
+		},
+		{
+			name: "DSN overrides discrete fields",
+			opts: ConnOptions{DSN: "postgres://admin:secret@pg:6432/main?sslmode=require", User: "app", Host: "db"},
+			dsn:  "postgres://admin:secret@pg:6432/main?sslmode=require",
+		},
+		{
+			name: "options override DSN",
+			opts: ConnOptions{DSN: "postgres://admin:secret@pg:6432/main?sslmode=require", SSLMode: "verify-ca"},
+			dsn:  "postgres://admin:secret@pg:6432/main?sslmode=verify-ca",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.dsn, tt.opts.ConnString())
+		})
+	}
+}
+
+func TestNewConnector(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c, err := NewConnector("postgres://app:secret@db:5432/app?sslmode=disable&statement_timeout=1000")
+		require.NoError(t, err)
+		assert.Equal(t, "db:5432", c.current.Load().Config().Addr)
+		assert.Equal(t, map[string]any{"statement_timeout": "1000"}, c.current.Load().Config().ConnParams)
+	})
+	t.Run("Error", func(t *testing.T) {
+		_, err := NewConnector("postgres://app:secret@db:5432/app?sslmode=unknown")
+		assert.EqualError(t, err, "invalid database DSN: pgdriver: sslmode 'unknown' is not supported")
+		_, err = NewConnector("postgres://app:secret@db:5432/app?sslmode=require&sslcert=missing.crt&sslkey=missing.key")
+		assert.ErrorContains(t, err, "can't load database client certificate")
+		_, err = NewConnector("postgres://app:secret@db:5432/app?sslmode=disable&sslcert=tls.crt&sslkey=tls.key")
+		assert.EqualError(t, err, "database client certificate is set, but SSL is disabled with sslmode=disable")
+		_, err = NewConnector("postgres://app:secret@db:port/app")
+		assert.EqualError(t, err, "invalid database DSN")
+	})
+}
@@ -1,27 +1,36 @@
 package database
 
 import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
-	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/extra/bundebug"
+	"io/fs"
+	"os"
 	"regexp"
 	"time"
 )
 
-const URLTemplate = "postgresql://%s:%s@%s:%s/%s?sslmode=disable" // where %s and %d defined as: DB_USER, DB_PASS, DB_HOST, DB_PORT, DB_NAME
+const (
+	migrationLockName         = "app-migrations"
+	migrationLockPollInterval = time.Second
+)
 
 var (
-	ErrNotFound  = fmt.Errorf("not found")
-	ErrDuplicate = fmt.Errorf("duplicate")
+	ErrNotFound             = fmt.Errorf("not found")
+	ErrDuplicate            = fmt.Errorf("duplicate")
+	ErrDirtySchema          = fmt.Errorf("dirty schema")
+	ErrMigrationLockTimeout = fmt.Errorf("timeout: can't acquire migration lock")
 )
 
 type IDB interface {
@@ -31,7 +40,8 @@ type IDB interface {
 
 type DB struct {
 	*bun.DB
-	id string
+	id        string
+	connector *Connector
 }
 
 func (db *DB) BunDB() *bun.DB {
@@ -46,6 +56,11 @@ func (db *DB) ID() string {
 	return db.id
 }
 
+// SetDSN replaces DSN for new connections, e.g. when the password is rotated.
+func (db *DB) SetDSN(dsn string) error {
+	return db.connector.SetDSN(dsn)
+}
+
 type Tx struct {
 	bun.Tx
 	id string
@@ -55,24 +70,25 @@ func (t Tx) ID() string {
 	return t.id
 }
 
-// NewDatabase creates new SQL database instance.
-func NewDatabase(dsn string, debug bool) (*DB, error) {
-	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
+// OpenDatabase creates new SQL database instance without connecting to the database,
+// e.g. to wait for the database with PingWithRetry. Queries logged in debug mode are redacted with redactor.
+func OpenDatabase(dsn string, pool PoolOptions, debug bool, redactor *redact.Redactor) (*DB, error) {
+	connector, err := NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	sqldb := sql.OpenDB(connector)
+	pool.apply(sqldb)
 	bundb := bun.NewDB(sqldb, pgdialect.New())
 	// log queries when debug mode is set
 	bundb.AddQueryHook(
 		bundebug.NewQueryHook(
 			bundebug.WithEnabled(debug),
 			bundebug.WithVerbose(true),
+			bundebug.WithWriter(redactor.Writer(os.Stderr)),
 		),
 	)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	err := bundb.PingContext(ctx)
-	if err != nil {
-		return nil, err
-	}
-	db := &DB{DB: bundb, id: uuid.Must(uuid.NewUUID()).String()}
+	db := &DB{DB: bundb, id: uuid.Must(uuid.NewUUID()).String(), connector: connector}
 	return db, nil
 }
 
@@ -95,21 +111,111 @@ func IsDuplicate(err error) bool {
 	return false
 }
 
-func MigrateDBSchema(db *sql.DB, migrationsPath, dbName string) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
+// MigrateDBSchema applies all up migrations. It is a no-op when the schema is up-to-date.
+// Concurrent callers are serialized with WithMigrationLock, lockTimeout limits waiting for the lock.
+func MigrateDBSchema(db *sql.DB, migrations fs.FS, dbName, migrationsTable string, lockTimeout time.Duration) error {
+	ctx := context.Background()
+	return WithMigrationLock(ctx, db, dbName, lockTimeout, func(conn *sql.Conn) error {
+		m, err := NewMigrator(ctx, conn, migrations, dbName, migrationsTable)
+		if err != nil {
+			return err
+		}
+		m.LockTimeout = lockTimeout
+
+		err = m.Up()
+		var errDirty migrate.ErrDirty
+		if errors.Is(err, migrate.ErrNoChange) {
+			return nil
+		} else if errors.As(err, &errDirty) {
+			return fmt.Errorf("%w: version %d, fix the schema and force the version", ErrDirtySchema, errDirty.Version)
+		}
 		return err
+	})
+}
+
+// NewMigrator returns schema migrator for migration files in the root of migrations file system.
+// Applied migrations are tracked in migrationsTable, the default table is used when it is empty.
+// The migrator runs on conn, e.g. the one of WithMigrationLock, so it doesn't take another connection from the pool.
+// conn stays owned by the caller: the migrator must not be closed, as closing it closes conn.
+func NewMigrator(ctx context.Context, conn *sql.Conn, migrations fs.FS, dbName, migrationsTable string) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations, ".")
+	if err != nil {
+		return nil, err
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{MigrationsTable: migrationsTable})
+	if err != nil {
+		return nil, err
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://"+migrationsPath,
-		dbName,
-		driver)
+	return migrate.NewWithInstance("iofs", source, dbName, driver)
+}
+
+// WithMigrationLock calls f holding Postgres advisory lock, so only one app replica migrates the schema at a time.
+// It waits for the lock at most lockTimeout and returns ErrMigrationLockTimeout if the lock isn't acquired.
+// f gets the connection holding the lock, which is released to the pool when f returns.
+func WithMigrationLock(ctx context.Context, db *sql.DB, dbName string, lockTimeout time.Duration, f func(conn *sql.Conn) error) error {
+	lockID, err := migratedb.GenerateAdvisoryLockId(dbName, migrationLockName)
+	if err != nil {
+		return err
+	}
+	// session-level advisory lock belongs to a connection, so the same one must be used to release it
+	conn, err := db.Conn(ctx)
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
+
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	for {
+		var locked bool
+		err = conn.QueryRowContext(lockCtx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&locked)
+		if lockCtx.Err() != nil {
+			return ErrMigrationLockTimeout
+		} else if err != nil {
+			return err
+		} else if locked {
+			break
+		}
+		select {
+		case <-lockCtx.Done():
+			return ErrMigrationLockTimeout
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+	}()
+
+	return f(conn)
+}
+
+// SchemaVersion returns the current schema version from migrationsTable and whether the last migration failed.
+// migrate.ErrNilVersion is returned when no migrations were applied.
+func SchemaVersion(ctx context.Context, db bun.IDB, migrationsTable string) (version uint, dirty bool, err error) {
+	if migrationsTable == "" {
+		migrationsTable = postgres.DefaultMigrationsTable
+	}
+	err = db.NewSelect().
+		Table(migrationsTable).
+		Column("version", "dirty").
+		Limit(1).
+		Scan(ctx, &version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, migrate.ErrNilVersion
+	}
+	return version, dirty, err
+}
 
-	return m.Up()
+// CheckSchema returns an error when no migrations were applied or the last migration failed.
+func CheckSchema(ctx context.Context, db bun.IDB, migrationsTable string) error {
+	version, dirty, err := SchemaVersion(ctx, db, migrationsTable)
+	if err != nil {
+		return err
+	} else if dirty {
+		return fmt.Errorf("%w: version %d", ErrDirtySchema, version)
+	}
+	return nil
 }
 
 type TransactionFunc func(ctx context.Context, f func(tctx context.Context) error) error
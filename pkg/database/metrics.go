@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/uptrace/bun"
+	"strings"
+	"time"
+)
+
+// unknownTable labels queries which table isn't known, e.g. raw queries.
+const unknownTable = "unknown"
+
+// QueryMetrics is a bun query hook measuring duration and errors of queries by operation, e.g. SELECT, and table.
+type QueryMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+var _ bun.QueryHook = (*QueryMetrics)(nil)
+
+// NewQueryMetrics registers query metrics in reg.
+func NewQueryMetrics(reg prometheus.Registerer) *QueryMetrics {
+	m := &QueryMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of database queries.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"operation", "table"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Number of failed database queries.",
+		}, []string{"operation", "table"}),
+	}
+	reg.MustRegister(m.duration, m.errors)
+	return m
+}
+
+func (m *QueryMetrics) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (m *QueryMetrics) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	operation := event.Operation()
+	table := queryTable(event)
+	if table == "" {
+		table = unknownTable
+	}
+	m.duration.WithLabelValues(operation, table).Observe(time.Since(event.StartTime).Seconds())
+	// no rows is a result rather than a failure, see IsNotFound
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		m.errors.WithLabelValues(operation, table).Inc()
+	}
+}
+
+// queryTable returns the table of the query or an empty string.
+func queryTable(event *bun.QueryEvent) string {
+	if event.IQuery == nil {
+		return ""
+	}
+	// tables set with Table() are quoted identifiers
+	return strings.Trim(event.IQuery.GetTableName(), `"`)
+}
+
+// RegisterMetrics measures queries with QueryMetrics and exports connection pool stats, see sql.DBStats,
+// which are read on every scrape. dbName labels the pool stats. It should be called before the db is used.
+func (db *DB) RegisterMetrics(reg prometheus.Registerer, dbName string) {
+	db.AddQueryHook(NewQueryMetrics(reg))
+	reg.MustRegister(collectors.NewDBStatsCollector(db.SqlDB(), dbName))
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDSN returns DSN of a test database from TEST_DB_DSN, e.g. the database of docker-compose.yml.
+// The test is skipped in short mode or when it isn't set.
+func testDSN(t *testing.T) string {
+	dsn := os.Getenv("TEST_DB_DSN")
+	if testing.Short() || dsn == "" {
+		t.Skip("integration test, set TEST_DB_DSN to run it")
+	}
+	return dsn
+}
+
+func TestMigrateDBSchema_SingleConnection(t *testing.T) {
+	dsn := testDSN(t)
+	db, err := OpenDatabase(dsn, PoolOptions{MaxOpenConns: 1}, false, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.Ping())
+	migrations := fstest.MapFS{
+		"1_create_migration_test.up.sql":   {Data: []byte("CREATE TABLE IF NOT EXISTS migration_test (id int);")},
+		"1_create_migration_test.down.sql": {Data: []byte("DROP TABLE migration_test;")},
+	}
+
+	// the lock connection is the only one, so the migration deadlocks if it takes another
+	for i := 0; i < 2; i++ {
+		require.NoError(t, MigrateDBSchema(db.SqlDB(), migrations, "app", "migration_test_migrations", 5*time.Second))
+	}
+
+	// a connection leaked by the migrator would block the query
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	version, dirty, err := SchemaVersion(ctx, db, "migration_test_migrations")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), version)
+	assert.False(t, dirty)
+}
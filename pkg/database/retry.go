@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	logs "github.com/sirupsen/logrus"
+	"math/rand"
+	"time"
+)
+
+// RetryOptions configure connection attempts with exponential backoff and jitter.
+type RetryOptions struct {
+	// Timeout limits the total time of attempts, zero means a single attempt.
+	Timeout         time.Duration
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// PingWithRetry pings the database until it responds or the retry timeout is reached.
+func (db *DB) PingWithRetry(ctx context.Context, opts RetryOptions) error {
+	return retry(ctx, opts, db.PingContext)
+}
+
+// retry calls f until it succeeds, ctx is done or the retry timeout is reached.
+// Intervals between attempts grow exponentially with random jitter of up to half an interval.
+func retry(ctx context.Context, opts RetryOptions, f func(ctx context.Context) error) error {
+	if opts.Timeout <= 0 {
+		return f(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	interval := opts.InitialInterval
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+
+		sleep := interval/2 + time.Duration(rnd.Int63n(int64(interval/2)+1))
+		if deadline, _ := ctx.Deadline(); time.Until(deadline) < sleep {
+			return fmt.Errorf("database isn't available after %d attempts: %w", attempt, err)
+		}
+		logs.WithFields(logs.Fields{"attempt": attempt, "retry_in": sleep.String()}).
+			Warnf("Database isn't available; error: %v", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database isn't available after %d attempts: %w", attempt, err)
+		case <-time.After(sleep):
+		}
+
+		if interval *= 2; interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
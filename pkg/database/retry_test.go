@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	opts := RetryOptions{Timeout: time.Second, InitialInterval: 10 * time.Millisecond, MaxInterval: 20 * time.Millisecond}
+	errUnavailable := errors.New("unavailable")
+
+	t.Run("OK", func(t *testing.T) {
+		attempts := 0
+		err := retry(context.Background(), opts, func(ctx context.Context) error {
+			if attempts++; attempts < 3 {
+				return errUnavailable
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+	t.Run("Error", func(t *testing.T) {
+		opts := opts
+		opts.Timeout = 50 * time.Millisecond
+		start := time.Now()
+		err := retry(context.Background(), opts, func(ctx context.Context) error {
+			return errUnavailable
+		})
+		assert.ErrorIs(t, err, errUnavailable)
+		assert.Contains(t, err.Error(), "database isn't available after")
+		assert.Less(t, time.Since(start), opts.Timeout+opts.MaxInterval)
+	})
+	t.Run("single attempt without timeout", func(t *testing.T) {
+		attempts := 0
+		err := retry(context.Background(), RetryOptions{}, func(ctx context.Context) error {
+			attempts++
+			return errUnavailable
+		})
+		assert.ErrorIs(t, err, errUnavailable)
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	"bitbucket.org/creativeadvtech/project-template/pkg/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracing is a bun query hook starting a client span per query, e.g. SELECT objects.
+// Statements are redacted with redactor as they include query arguments.
+type QueryTracing struct {
+	redactor *redact.Redactor
+}
+
+var _ bun.QueryHook = (*QueryTracing)(nil)
+
+// NewQueryTracing returns the query hook. Spans are started with the global tracer provider.
+func NewQueryTracing(redactor *redact.Redactor) *QueryTracing {
+	return &QueryTracing{redactor: redactor}
+}
+
+func (h *QueryTracing) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	operation := event.Operation()
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationKey.String(operation),
+		semconv.DBStatementKey.String(h.redactor.String(event.Query)),
+	}
+	name := operation
+	if table := queryTable(event); table != "" {
+		attrs = append(attrs, semconv.DBSQLTableKey.String(table))
+		name += " " + table
+	}
+	ctx, _ = tracing.Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (h *QueryTracing) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	// no rows is a result rather than a failure, see IsNotFound
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+}
+
+// RegisterTracing traces queries with QueryTracing. It should be called before the db is used.
+func (db *DB) RegisterTracing(redactor *redact.Redactor) {
+	db.AddQueryHook(NewQueryTracing(redactor))
+}
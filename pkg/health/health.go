@@ -0,0 +1,162 @@
+package health
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/rest"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is a status of a check or of the whole report.
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusDegraded    Status = "degraded"    // some non-critical checks failed
+	StatusUnavailable Status = "unavailable" // some critical checks failed
+	StatusFailed      Status = "failed"      // status of a failed check
+)
+
+// CheckFunc checks a dependency and returns an error when it is unavailable.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named dependency check.
+type Check struct {
+	Name string
+	// Critical check failure makes the whole report unavailable, otherwise it's only degraded.
+	Critical bool
+	// Timeout limits the check duration, registry's default timeout is used when it is zero.
+	Timeout time.Duration
+	Check   CheckFunc
+}
+
+// CheckResult is a result of a single check.
+type CheckResult struct {
+	Status   Status  `json:"status"`
+	Critical bool    `json:"critical"`
+	Latency  float64 `json:"latency_ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Report is a result of all registered checks.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Registry runs registered checks and caches the report for a short time, so probes don't hammer dependencies.
+type Registry struct {
+	cacheTTL time.Duration
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	checks   []Check
+	report   Report
+	reportAt time.Time
+}
+
+// NewRegistry returns an empty registry. Reports are cached for cacheTTL, checks without timeout are limited by timeout.
+func NewRegistry(cacheTTL, timeout time.Duration) *Registry {
+	return &Registry{
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+	}
+}
+
+// Register adds the check to the registry.
+func (r *Registry) Register(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check)
+	r.reportAt = time.Time{}
+}
+
+// Run runs all checks concurrently or returns the cached report. The report is shared by all callers, so checks
+// keep ctx values but not its cancellation: a probe giving up early doesn't fail the cached report.
+func (r *Registry) Run(ctx context.Context) Report {
+	// concurrent callers wait for the running checks and get the same report
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.reportAt.IsZero() && time.Since(r.reportAt) < r.cacheTTL {
+		return r.report
+	}
+
+	results := make([]CheckResult, len(r.checks))
+	var wg sync.WaitGroup
+	for i, check := range r.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = r.runCheck(detached{ctx}, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(r.checks))}
+	for i, check := range r.checks {
+		result := results[i]
+		report.Checks[check.Name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if check.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	r.report, r.reportAt = report, time.Now()
+	return report
+}
+
+func (r *Registry) runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = r.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	result := CheckResult{
+		Status:   StatusOK,
+		Critical: check.Critical,
+		Latency:  float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// detached is a context with parent values, but without its deadline and cancellation.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+// Handler returns the registry report. The response status is 503 when a critical check fails.
+func Handler(registry *Registry) rest.APIHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		report := registry.Run(r.Context())
+		status := http.StatusOK
+		if report.Status == StatusUnavailable {
+			status = http.StatusServiceUnavailable
+		}
+		return rest.WriteJSON(w, report, status)
+	}
+}
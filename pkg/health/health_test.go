@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okCheck(context.Context) error {
+	return nil
+}
+
+func failedCheck(context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestRegistry_Run(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Check{Name: "db", Critical: true, Check: okCheck})
+
+		report := registry.Run(context.Background())
+
+		assert.Equal(t, StatusOK, report.Status)
+		assert.Equal(t, StatusOK, report.Checks["db"].Status)
+		assert.True(t, report.Checks["db"].Critical)
+	})
+
+	t.Run("failed non-critical check degrades the report", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Check{Name: "db", Critical: true, Check: okCheck})
+		registry.Register(Check{Name: "cache", Check: failedCheck})
+
+		report := registry.Run(context.Background())
+
+		assert.Equal(t, StatusDegraded, report.Status)
+		assert.Equal(t, CheckResult{Status: StatusFailed, Error: "connection refused"},
+			CheckResult{Status: report.Checks["cache"].Status, Error: report.Checks["cache"].Error})
+	})
+
+	t.Run("failed critical check makes the report unavailable", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Check{Name: "db", Critical: true, Check: failedCheck})
+		registry.Register(Check{Name: "cache", Check: failedCheck})
+
+		report := registry.Run(context.Background())
+
+		assert.Equal(t, StatusUnavailable, report.Status)
+	})
+
+	t.Run("check is limited by timeout", func(t *testing.T) {
+		registry := NewRegistry(0, 10*time.Millisecond)
+		registry.Register(Check{Name: "db", Critical: true, Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}})
+
+		report := registry.Run(context.Background())
+
+		assert.Equal(t, StatusUnavailable, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["db"].Error)
+	})
+
+	t.Run("canceled caller doesn't fail the report", func(t *testing.T) {
+		registry := NewRegistry(time.Minute, time.Second)
+		registry.Register(Check{Name: "db", Critical: true, Check: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+				return nil
+			}
+		}})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report := registry.Run(ctx)
+
+		assert.Equal(t, StatusOK, report.Status)
+		assert.Equal(t, StatusOK, registry.Run(context.Background()).Checks["db"].Status)
+	})
+
+	t.Run("report is cached", func(t *testing.T) {
+		calls := 0
+		registry := NewRegistry(time.Minute, time.Second)
+		registry.Register(Check{Name: "db", Check: func(context.Context) error {
+			calls++
+			return nil
+		}})
+
+		registry.Run(context.Background())
+		registry.Run(context.Background())
+
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/health/live", nil)
+
+		err := Handler(registry)(w, r)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status": "ok"}`, w.Body.String())
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Check{Name: "db", Critical: true, Check: failedCheck})
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+
+		err := Handler(registry)(w, r)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), `"error":"connection refused"`)
+	})
+}
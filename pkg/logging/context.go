@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"context"
+	logs "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// Request field names, see https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html.
+const (
+	FieldRequestID = "http.request.id"
+	FieldRoute     = "http.route"
+	FieldPrincipal = "user.name"
+	FieldTraceID   = "trace.id"
+	FieldSpanID    = "span.id"
+)
+
+type contextKey struct{}
+
+// contextEntry is a request log entry shared by middlewares, which add fields as they learn about the request.
+type contextEntry struct {
+	mu    sync.RWMutex
+	entry *logs.Entry
+}
+
+// WithContext returns a copy of ctx carrying the entry. FromContext returns it with fields added by AddFields.
+func WithContext(ctx context.Context, entry *logs.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &contextEntry{entry: entry})
+}
+
+// FromContext returns the entry set with WithContext bound to ctx, or an entry of the global logger.
+func FromContext(ctx context.Context) *logs.Entry {
+	ce, ok := ctx.Value(contextKey{}).(*contextEntry)
+	if !ok {
+		return logs.NewEntry(logs.StandardLogger()).WithContext(ctx)
+	}
+	ce.mu.RLock()
+	defer ce.mu.RUnlock()
+	return ce.entry.WithContext(ctx)
+}
+
+// AddFields adds fields to the entry set with WithContext, including copies returned by FromContext later.
+// It does nothing when ctx has no entry.
+func AddFields(ctx context.Context, fields logs.Fields) {
+	ce, ok := ctx.Value(contextKey{}).(*contextEntry)
+	if !ok {
+		return
+	}
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.entry = ce.entry.WithFields(fields)
+}
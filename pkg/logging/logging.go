@@ -1,17 +1,77 @@
 package logging
 
 import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
 	logs "github.com/sirupsen/logrus"
+	"time"
 )
 
-func Init(logLevel string) {
+// Log formats.
+const (
+	FormatText   = "text"   // human-readable, colored on terminals
+	FormatJSON   = "json"   // JSON object per line
+	FormatLogfmt = "logfmt" // key=value pairs
+)
+
+// ECS field names, see https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html.
+const (
+	FieldTimestamp      = "@timestamp"
+	FieldLevel          = "log.level"
+	FieldMessage        = "message"
+	FieldError          = "error.message"
+	FieldErrorCode      = "error.code"
+	FieldOriginFunction = "log.origin.function"
+	FieldOriginFile     = "log.origin.file.name"
+)
+
+// ecsFieldMap renames logrus default fields to ECS field names.
+var ecsFieldMap = logs.FieldMap{
+	logs.FieldKeyTime:  FieldTimestamp,
+	logs.FieldKeyLevel: FieldLevel,
+	logs.FieldKeyMsg:   FieldMessage,
+	logs.FieldKeyFunc:  FieldOriginFunction,
+	logs.FieldKeyFile:  FieldOriginFile,
+}
+
+// Options configure a logger.
+type Options struct {
+	Level        string
+	Format       string
+	ReportCaller bool
+	// Redactor redacts messages and fields, e.g. tokens in errors.
+	Redactor *redact.Redactor
+}
+
+// Init configures the global logger. It also renames the error field set with WithError for all loggers.
+func Init(opts Options) {
+	logs.ErrorKey = FieldError
+	Configure(logs.StandardLogger(), opts)
+}
+
+// Configure sets level, formatter, caller reporting and redaction of the logger.
+// Debug level and text format are used by default.
+func Configure(logger *logs.Logger, opts Options) {
 	// parse string, this is built-in feature of logrus
-	ll, err := logs.ParseLevel(logLevel)
+	ll, err := logs.ParseLevel(opts.Level)
 	if err != nil {
 		ll = logs.DebugLevel
 	}
-	// set global log level
-	logs.SetLevel(ll)
-	logs.SetReportCaller(true)
-	logs.SetFormatter(&logs.TextFormatter{})
+	logger.SetLevel(ll)
+	logger.SetReportCaller(opts.ReportCaller)
+	logger.SetFormatter(NewFormatter(opts.Format))
+	if opts.Redactor != nil {
+		logger.AddHook(redactHook{redactor: opts.Redactor})
+	}
+}
+
+// NewFormatter returns formatter of the format with ECS field names.
+func NewFormatter(format string) logs.Formatter {
+	switch format {
+	case FormatJSON:
+		return &logs.JSONFormatter{TimestampFormat: time.RFC3339Nano, FieldMap: ecsFieldMap}
+	case FormatLogfmt:
+		return &logs.TextFormatter{DisableColors: true, FullTimestamp: true, TimestampFormat: time.RFC3339Nano, FieldMap: ecsFieldMap}
+	default:
+		return &logs.TextFormatter{FieldMap: ecsFieldMap}
+	}
 }
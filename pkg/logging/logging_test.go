@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	logs "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigure(t *testing.T) {
+	newLogger := func(opts Options) (*logs.Logger, *bytes.Buffer) {
+		var buf bytes.Buffer
+		logger := logs.New()
+		logger.SetOutput(&buf)
+		Configure(logger, opts)
+		return logger, &buf
+	}
+
+	t.Run("JSON with ECS field names", func(t *testing.T) {
+		logger, buf := newLogger(Options{Level: "info", Format: FormatJSON, ReportCaller: true})
+		logger.WithField("url.path", "/v1").Info("served")
+		logger.Debug("skipped")
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "info", entry[FieldLevel])
+		assert.Equal(t, "served", entry[FieldMessage])
+		assert.Equal(t, "/v1", entry["url.path"])
+		assert.NotEmpty(t, entry[FieldTimestamp])
+		assert.Contains(t, entry[FieldOriginFunction], "TestConfigure")
+		assert.Contains(t, entry[FieldOriginFile], "logging_test.go")
+	})
+	t.Run("logfmt", func(t *testing.T) {
+		logger, buf := newLogger(Options{Level: "debug", Format: FormatLogfmt})
+		logger.Debug("served")
+
+		assert.Contains(t, buf.String(), "log.level=debug message=served")
+		assert.NotContains(t, buf.String(), "log.origin")
+	})
+	t.Run("defaults", func(t *testing.T) {
+		logger, _ := newLogger(Options{Level: "unknown"})
+		assert.Equal(t, logs.DebugLevel, logger.Level)
+		assert.IsType(t, &logs.TextFormatter{}, logger.Formatter)
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("returns entry with added fields", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logs.New()
+		logger.SetOutput(&buf)
+		Configure(logger, Options{Format: FormatJSON})
+		ctx := WithContext(context.Background(), logger.WithField(FieldRequestID, "req-1"))
+		AddFields(ctx, logs.Fields{FieldPrincipal: "CN=service"})
+
+		FromContext(ctx).Info("served")
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "req-1", entry[FieldRequestID])
+		assert.Equal(t, "CN=service", entry[FieldPrincipal])
+	})
+	t.Run("falls back to global logger", func(t *testing.T) {
+		ctx := context.Background()
+		AddFields(ctx, logs.Fields{FieldPrincipal: "CN=service"})
+
+		entry := FromContext(ctx)
+		assert.Same(t, logs.StandardLogger(), entry.Logger)
+		assert.Empty(t, entry.Data)
+		assert.Equal(t, ctx, entry.Context)
+	})
+}
+
+func TestConfigure_Redactor(t *testing.T) {
+	redactor, err := redact.New(redact.Options{Fields: []string{"password"}})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	logger := logs.New()
+	logger.SetOutput(&buf)
+	Configure(logger, Options{Format: FormatJSON, Redactor: redactor})
+
+	logger.WithFields(logs.Fields{
+		"password": "s3cret",
+		"body":     `{"password": "s3cret"}`,
+		"headers":  http.Header{"Authorization": {"Bearer abc"}},
+	}).WithError(errors.New("dial postgres://app:s3cret@db/app")).Error("token Bearer abc")
+
+	assert.NotContains(t, buf.String(), "s3cret")
+	assert.NotContains(t, buf.String(), "abc")
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "token Bearer ******", entry[FieldMessage])
+	assert.Equal(t, redact.Mask, entry["password"])
+}
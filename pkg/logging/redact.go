@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	logs "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// redactHook redacts messages and fields of entries before they are formatted.
+type redactHook struct {
+	redactor *redact.Redactor
+}
+
+func (h redactHook) Levels() []logs.Level {
+	return logs.AllLevels
+}
+
+func (h redactHook) Fire(entry *logs.Entry) error {
+	entry.Message = h.redactor.String(entry.Message)
+	for key, value := range entry.Data {
+		if h.redactor.Field(key) {
+			entry.Data[key] = redact.Mask
+			continue
+		}
+		switch value := value.(type) {
+		case string:
+			entry.Data[key] = h.redactor.String(value)
+		case error:
+			entry.Data[key] = h.redactor.String(value.Error())
+		case http.Header:
+			entry.Data[key] = h.redactor.Header(value)
+		}
+	}
+	return nil
+}
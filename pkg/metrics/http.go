@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels requests which don't match any route, e.g. 404 on random paths.
+const unmatchedRoute = "unmatched"
+
+// knownMethods bound cardinality of method label, other methods are labelled OTHER.
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true,
+	http.MethodDelete: true, http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// HTTP is RED metrics of HTTP requests: rate, errors and duration.
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+// NewHTTP registers HTTP metrics in reg.
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	m := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of served HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being served.",
+		}, []string{"method", "route"}),
+	}
+	reg.MustRegister(m.requests, m.duration, m.inFlight)
+	return m
+}
+
+// Middleware measures requests. Requests are labelled by chi route pattern, e.g. /v1/objects/{ObjectID},
+// which is matched with routes before routing, and status class, e.g. 2xx, so cardinality stays bounded.
+// It should go before middleware.Recoverer to count panics as 5xx.
+func (m *HTTP) Middleware(routes chi.Routes) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method := r.Method
+			if !knownMethods[method] {
+				method = "OTHER"
+			}
+			route := matchRoute(routes, r)
+			inFlight := m.inFlight.WithLabelValues(method, route)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					// nothing is written
+					status = http.StatusOK
+				}
+				class := strconv.Itoa(status/100) + "xx"
+				m.requests.WithLabelValues(method, route, class).Inc()
+				m.duration.WithLabelValues(method, route, class).Observe(time.Since(start).Seconds())
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// matchRoute returns the route pattern of the request.
+func matchRoute(routes chi.Routes, r *http.Request) string {
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	rctx := chi.NewRouteContext()
+	if !routes.Match(rctx, r.Method, path) {
+		return unmatchedRoute
+	}
+	return rctx.RoutePattern()
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP_Middleware(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewHTTP(reg)
+	router := chi.NewRouter()
+	router.Use(m.Middleware(router))
+	router.Route("/v1/objects", func(r chi.Router) {
+		r.Get("/{ObjectID}", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, 1.0, testutil.ToFloat64(m.inFlight.WithLabelValues(http.MethodGet, "/v1/objects/{ObjectID}")))
+			if chi.URLParam(r, "ObjectID") == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+	})
+
+	for _, path := range []string{"/v1/objects/1", "/v1/objects/2", "/v1/objects/missing", "/random/path"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/v1/objects/1", nil))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/v1/objects/{ObjectID}", "2xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/v1/objects/{ObjectID}", "4xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, unmatchedRoute, "4xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("OTHER", unmatchedRoute, "4xx")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.inFlight.WithLabelValues(http.MethodGet, "/v1/objects/{ObjectID}")))
+	assert.Equal(t, 4, testutil.CollectAndCount(m.duration))
+}
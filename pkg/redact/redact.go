@@ -0,0 +1,247 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces redacted values.
+const Mask = "******"
+
+// builtinPatterns redact credentials in well-known formats: bearer tokens, URL passwords and password parameters.
+var builtinPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bbearer\s+([^\s"';]+)`),
+	regexp.MustCompile(`://[^:/@\s]*:([^@\s]+)@`),
+	regexp.MustCompile(`(?i)\b(?:password|passwd|pwd)=([^&\s"']+)`),
+}
+
+// Options configure a Redactor.
+type Options struct {
+	// Headers are names of HTTP headers which values are redacted.
+	Headers []string
+	// Fields are dot-separated paths of JSON fields which values are redacted. A path matches at any depth,
+	// e.g. user.password matches {"data": {"user": {"password": "..."}}}, array elements are skipped in paths.
+	Fields []string
+	// Patterns are regular expressions which matches are redacted in strings. When a pattern has groups,
+	// only the groups are redacted, e.g. the password in `password=(\S+)`.
+	Patterns []string
+}
+
+// Redactor removes sensitive values from logs and error reports. A nil Redactor doesn't redact anything.
+type Redactor struct {
+	headers  map[string]bool
+	fields   [][]string
+	patterns []*regexp.Regexp
+	// fieldPatterns match the last segments of fields in text which isn't valid JSON, e.g. a truncated body.
+	fieldPatterns []*regexp.Regexp
+}
+
+// New returns a Redactor. Built-in patterns are always applied in addition to opts.Patterns.
+func New(opts Options) (*Redactor, error) {
+	r := &Redactor{
+		headers:  make(map[string]bool, len(opts.Headers)),
+		patterns: append([]*regexp.Regexp(nil), builtinPatterns...),
+	}
+	for _, header := range opts.Headers {
+		r.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	keys := make(map[string]bool)
+	for _, field := range opts.Fields {
+		path := strings.Split(strings.ToLower(field), ".")
+		r.fields = append(r.fields, path)
+		if key := path[len(path)-1]; !keys[key] {
+			keys[key] = true
+			r.fieldPatterns = append(r.fieldPatterns,
+				regexp.MustCompile(`(?i)"`+regexp.QuoteMeta(key)+`"\s*:\s*(?:"((?:[^"\\]|\\.)*)"?|([^\s,}\]"]+))`))
+		}
+	}
+	for _, pattern := range opts.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern: %w", err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// String redacts pattern matches in s. JSON documents also have their fields redacted,
+// other text has values of JSON fields with the same names redacted regardless of paths.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	isJSON := false
+	if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var redacted []byte
+		if redacted, isJSON = r.json([]byte(s)); isJSON {
+			s = string(redacted)
+		}
+	}
+	if !isJSON {
+		// JSON fields embedded in text or truncated JSON
+		for _, re := range r.fieldPatterns {
+			s = replace(re, s)
+		}
+	}
+	for _, re := range r.patterns {
+		s = replace(re, s)
+	}
+	return s
+}
+
+// JSON redacts fields and pattern matches in the JSON document. Invalid JSON is redacted as a string.
+func (r *Redactor) JSON(data []byte) []byte {
+	if r == nil {
+		return data
+	}
+	return []byte(r.String(string(data)))
+}
+
+// Field reports whether the dot-separated field path, e.g. a log field name, is sensitive.
+func (r *Redactor) Field(path string) bool {
+	return r != nil && r.matchField(strings.Split(strings.ToLower(path), "."))
+}
+
+// HeaderValue redacts the value of the named header.
+func (r *Redactor) HeaderValue(name, value string) string {
+	if r == nil {
+		return value
+	}
+	if r.headers[http.CanonicalHeaderKey(name)] {
+		return Mask
+	}
+	return r.String(value)
+}
+
+// Header returns a copy of h with redacted values.
+func (r *Redactor) Header(h http.Header) http.Header {
+	if r == nil {
+		return h
+	}
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		redacted[name] = make([]string, len(values))
+		for i, value := range values {
+			redacted[name][i] = r.HeaderValue(name, value)
+		}
+	}
+	return redacted
+}
+
+// Writer returns a writer which redacts every write to w, e.g. a line of a query log.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return writer{w: w, r: r}
+}
+
+type writer struct {
+	w io.Writer
+	r *Redactor
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// json redacts fields of the JSON document, ok is false when data isn't valid JSON.
+// The document is kept as is when there is nothing to redact.
+func (r *Redactor) json(data []byte) ([]byte, bool) {
+	if len(r.fields) == 0 {
+		return data, json.Valid(data)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return data, false
+	}
+	if !r.redactValue(doc, nil) {
+		return data, true
+	}
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return data, false
+	}
+	return redacted, true
+}
+
+// redactValue replaces values of sensitive fields in v, path is the path of v. It reports whether v is changed.
+func (r *Redactor) redactValue(v any, path []string) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			fieldPath := append(path[:len(path):len(path)], strings.ToLower(key))
+			if r.matchField(fieldPath) {
+				v[key] = Mask
+				changed = true
+			} else if r.redactValue(value, fieldPath) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if r.redactValue(value, path) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// matchField reports whether a configured field is a suffix of the path.
+func (r *Redactor) matchField(path []string) bool {
+	for _, field := range r.fields {
+		if len(field) > len(path) {
+			continue
+		}
+		match := true
+		for i, segment := range field {
+			if path[len(path)-len(field)+i] != segment {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// replace replaces matches of re in s with Mask, or only the groups when re has them.
+func replace(re *regexp.Regexp, s string) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllLiteralString(s, Mask)
+	}
+	var b strings.Builder
+	last := 0
+	for _, match := range re.FindAllStringSubmatchIndex(s, -1) {
+		for i := 2; i < len(match); i += 2 {
+			start, end := match[i], match[i+1]
+			if start < last {
+				// the group didn't participate or overlaps the previous one
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Mask)
+			last = end
+		}
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package redact
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedactor(t *testing.T) *Redactor {
+	r, err := New(Options{
+		Headers:  []string{"authorization", "X-Api-Key"},
+		Fields:   []string{"password", "user.email"},
+		Patterns: []string{`\b\d{4}-\d{4}-\d{4}-\d{4}\b`, `ssn=(\d+)`},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRedactor_String(t *testing.T) {
+	r := newTestRedactor(t)
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{name: "plain", in: "nothing to hide", out: "nothing to hide"},
+		{name: "bearer token", in: "Authorization: Bearer eyJhbGciOi.x.y", out: "Authorization: Bearer ******"},
+		{name: "URL password", in: "dial postgres://app:s3cret@db:5432/app", out: "dial postgres://app:******@db:5432/app"},
+		{name: "password parameter", in: "host=db password=s3cret user=app", out: "host=db password=****** user=app"},
+		{name: "pattern", in: "card 1234-5678-9012-3456 declined", out: "card ****** declined"},
+		{name: "pattern group", in: "ssn=123456789&x=1", out: "ssn=******&x=1"},
+		{
+			name: "JSON fields",
+			in:   `{"user": {"email": "a@example.com", "name": "A"}, "items": [{"password": "x"}], "email": "kept"}`,
+			out:  `{"email":"kept","items":[{"password":"******"}],"user":{"email":"******","name":"A"}}`,
+		},
+		{name: "JSON without fields", in: `{"name": "A"}`, out: `{"name": "A"}`},
+		{name: "truncated JSON", in: `{"user": {"email": "a@ex`, out: `{"user": {"email": "******`},
+		{name: "JSON in text", in: `invalid body: {"password": "x\"y", "pin": 1, "email": null}`, out: `invalid body: {"password": "******", "pin": 1, "email": ******}`},
+		{name: "SQL", in: `SELECT * FROM users WHERE card = '1234-5678-9012-3456'`, out: `SELECT * FROM users WHERE card = '******'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.out, r.String(tt.in))
+		})
+	}
+}
+
+func TestRedactor_Header(t *testing.T) {
+	r := newTestRedactor(t)
+	h := http.Header{"Authorization": {"Basic abc"}, "X-Api-Key": {"key"}, "Accept": {"application/json"}}
+
+	assert.Equal(t, http.Header{
+		"Authorization": {Mask},
+		"X-Api-Key":     {Mask},
+		"Accept":        {"application/json"},
+	}, r.Header(h))
+	assert.Equal(t, "Basic abc", h.Get("Authorization"), "the header must not be modified")
+}
+
+func TestRedactor_Writer(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTestRedactor(t).Writer(&buf)
+
+	n, err := w.Write([]byte("UPDATE users SET password = 'x' WHERE card = '1234-5678-9012-3456'\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 67, n)
+	assert.Equal(t, "UPDATE users SET password = 'x' WHERE card = '******'\n", buf.String())
+}
+
+func TestRedactor_Nil(t *testing.T) {
+	var r *Redactor
+	assert.Equal(t, "Bearer abc", r.String("Bearer abc"))
+	assert.Equal(t, "abc", r.HeaderValue("Authorization", "abc"))
+	assert.False(t, r.Field("password"))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Options{Patterns: []string{"("}})
+	assert.EqualError(t, err, "invalid redaction pattern: error parsing regexp: missing closing ): `(`")
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+)
+
+// limitedBody is a request body limited by BodyLimit. It keeps the original body, so a route can override the limit.
+type limitedBody struct {
+	io.ReadCloser
+	orig io.ReadCloser
+}
+
+// BodyLimit limits request body size, zero limit disables it. Reading a larger body fails,
+// and ReadBody returns 413 error then.
+// The innermost limit wins, so a route can set its own limit over the global one, e.g.
+//
+//	r.With(rest.BodyLimit(64 << 10)).Post("/", handler)
+func BodyLimit(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit > 0 && r.Body != nil {
+				body := r.Body
+				if limited, ok := body.(*limitedBody); ok {
+					body = limited.orig
+				}
+				r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, body, limit), orig: body}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
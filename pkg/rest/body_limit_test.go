@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(BodyLimit(16))
+	handler := APIHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		body := map[string]string{}
+		if err := ReadBody(r, &body); err != nil {
+			return err
+		}
+		return WriteNoContent(w)
+	})
+	router.Post("/", handler)
+	router.With(BodyLimit(64)).Post("/large", handler)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "OK", path: "/", body: `{"a":"b"}`, status: http.StatusNoContent},
+		{name: "global limit", path: "/", body: `{"a":"0123456789"}`, status: http.StatusRequestEntityTooLarge},
+		{name: "route limit overrides global", path: "/large", body: `{"a":"0123456789"}`, status: http.StatusNoContent},
+		{name: "route limit", path: "/large", body: `{"a":"` + strings.Repeat("0", 64) + `"}`, status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
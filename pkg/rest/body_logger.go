@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	"bytes"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	logs "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// BodyLogHeader enables body logging of the request when it's sent from BodyLogOptions.DebugNetworks.
+const BodyLogHeader = "X-Log-Body"
+
+// BodyLogOptions are options of body logging.
+type BodyLogOptions struct {
+	// Routes are chi route patterns, e.g. /v1/objects/{ObjectID}, which bodies are logged.
+	Routes []string
+	// DebugNetworks are networks of clients allowed to enable body logging with X-Log-Body: true header.
+	DebugNetworks []netip.Prefix
+	// TrustedProxies are used to get the client IP, see RemoteIP.
+	TrustedProxies []netip.Prefix
+	// MaxBytes limits the logged size of every body.
+	MaxBytes int
+	// SkipContentTypes are prefixes of content types which bodies aren't logged, e.g. multipart/.
+	SkipContentTypes []string
+	// Redactor redacts the bodies.
+	Redactor *redact.Redactor
+}
+
+// BodyLogger adds request and response bodies up to MaxBytes to the access log entry of AccessLogger.
+// Only the request body read by the handler is logged. Bodies are logged for Routes and for requests with
+// X-Log-Body header from DebugNetworks, it does nothing when neither is set.
+func BodyLogger(opts BodyLogOptions) func(next http.Handler) http.Handler {
+	routes := make(map[string]bool, len(opts.Routes))
+	for _, route := range opts.Routes {
+		routes[route] = true
+	}
+	return func(next http.Handler) http.Handler {
+		if (len(routes) == 0 && len(opts.DebugNetworks) == 0) || opts.MaxBytes <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			debug := false
+			if enabled, _ := strconv.ParseBool(r.Header.Get(BodyLogHeader)); enabled {
+				ip, err := netip.ParseAddr(RemoteIP(r, opts.TrustedProxies))
+				debug = err == nil && trusted(ip, opts.DebugNetworks)
+			}
+			// the route is known after routing, so bodies are captured lazily
+			rctx := chi.RouteContext(r.Context())
+			enabled := func() bool {
+				return debug || (rctx != nil && routes[rctx.RoutePattern()])
+			}
+
+			reqBody := &capturedBody{max: opts.MaxBytes, enabled: enabled}
+			if r.Body != nil && r.Body != http.NoBody && !opts.skip(r.Header.Get("Content-Type")) {
+				r.Body = &teeBody{ReadCloser: r.Body, w: reqBody}
+			}
+			resBody := &capturedBody{max: opts.MaxBytes, enabled: func() bool {
+				return enabled() && !opts.skip(w.Header().Get("Content-Type"))
+			}}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(resBody)
+
+			next.ServeHTTP(ww, r)
+
+			fields := logs.Fields{}
+			reqBody.addFields(fields, "http.request.body", opts.Redactor)
+			resBody.addFields(fields, "http.response.body", opts.Redactor)
+			if len(fields) > 0 {
+				logging.AddFields(r.Context(), fields)
+			}
+		})
+	}
+}
+
+func (o BodyLogOptions) skip(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range o.SkipContentTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// capturedBody keeps up to max bytes written while it's enabled.
+type capturedBody struct {
+	buf       bytes.Buffer
+	max       int
+	enabled   func() bool
+	truncated bool
+}
+
+func (b *capturedBody) Write(p []byte) (int, error) {
+	if len(p) == 0 || !b.enabled() {
+		return len(p), nil
+	}
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *capturedBody) addFields(fields logs.Fields, prefix string, redactor *redact.Redactor) {
+	if b.buf.Len() == 0 {
+		return
+	}
+	fields[prefix+".content"] = redactor.String(b.buf.String())
+	if b.truncated {
+		fields[prefix+".truncated"] = true
+	}
+}
+
+// teeBody writes the body to w as it's read.
+type teeBody struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = b.w.Write(p[:n])
+	}
+	return n, err
+}
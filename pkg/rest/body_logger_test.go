@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLogger(t *testing.T) {
+	redactor, err := redact.New(redact.Options{Fields: []string{"password"}})
+	require.NoError(t, err)
+	opts := BodyLogOptions{
+		Routes:           []string{"/v1/objects/{id}"},
+		DebugNetworks:    []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		MaxBytes:         64,
+		SkipContentTypes: []string{"multipart/"},
+		Redactor:         redactor,
+	}
+	serve := func(opts BodyLogOptions, r *http.Request) map[string]any {
+		var buf bytes.Buffer
+		logger := NewLoggerWithOptions(logging.Options{Level: "info", Format: logging.FormatJSON})
+		logger.SetOutput(&buf)
+		router := chi.NewRouter()
+		router.Use(AccessLogger(logger, AccessLogOptions{}), BodyLogger(opts))
+		echo := func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+			_, _ = w.Write(body)
+		}
+		router.Put("/v1/objects/{id}", echo)
+		router.Put("/v1/other", echo)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		return entry
+	}
+	newRequest := func(path, contentType, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.RemoteAddr = "203.0.113.7:1234"
+		return r
+	}
+
+	t.Run("logs redacted bodies of routes", func(t *testing.T) {
+		entry := serve(opts, newRequest("/v1/objects/1", "application/json", `{"login":"a","password":"s3cret"}`))
+
+		assert.Equal(t, `{"login":"a","password":"******"}`, entry["http.request.body.content"])
+		assert.Equal(t, `{"login":"a","password":"******"}`, entry["http.response.body.content"])
+		assert.NotContains(t, entry, "http.request.body.truncated")
+	})
+	t.Run("truncates bodies", func(t *testing.T) {
+		entry := serve(opts, newRequest("/v1/objects/1", "application/json", `{"data":"`+strings.Repeat("x", 100)+`"}`))
+
+		assert.Equal(t, `{"data":"`+strings.Repeat("x", 55), entry["http.request.body.content"])
+		assert.Equal(t, true, entry["http.request.body.truncated"])
+		assert.Equal(t, true, entry["http.response.body.truncated"])
+	})
+	t.Run("skips other routes", func(t *testing.T) {
+		entry := serve(opts, newRequest("/v1/other", "application/json", `{}`))
+
+		assert.NotContains(t, entry, "http.request.body.content")
+		assert.NotContains(t, entry, "http.response.body.content")
+	})
+	t.Run("skips content types", func(t *testing.T) {
+		entry := serve(opts, newRequest("/v1/objects/1", "multipart/form-data; boundary=x", "--x--"))
+
+		assert.NotContains(t, entry, "http.request.body.content")
+		assert.NotContains(t, entry, "http.response.body.content")
+	})
+	t.Run("debug header from allowed networks", func(t *testing.T) {
+		r := newRequest("/v1/other", "text/plain", "debug")
+		r.Header.Set(BodyLogHeader, "true")
+		entry := serve(opts, r)
+		assert.NotContains(t, entry, "http.request.body.content", "the client isn't in debug networks")
+
+		r = newRequest("/v1/other", "text/plain", "debug")
+		r.Header.Set(BodyLogHeader, "true")
+		r.RemoteAddr = "10.0.0.5:1234"
+		entry = serve(opts, r)
+		assert.Equal(t, "debug", entry["http.request.body.content"])
+		assert.Equal(t, "debug", entry["http.response.body.content"])
+	})
+	t.Run("disabled without routes and networks", func(t *testing.T) {
+		entry := serve(BodyLogOptions{MaxBytes: 64}, newRequest("/v1/objects/1", "application/json", `{}`))
+
+		assert.NotContains(t, entry, "http.request.body.content")
+	})
+}
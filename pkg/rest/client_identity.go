@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"context"
+	logs "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type clientIdentityKey struct{}
+
+// ClientIdentity is an identity of a client verified with mutual TLS.
+type ClientIdentity struct {
+	Subject        string
+	CommonName     string
+	DNSNames       []string
+	EmailAddresses []string
+	URIs           []string
+	SerialNumber   string
+}
+
+// ClientIdentityMiddleware puts identity from the verified client certificate into the request context.
+// The subject is added to the request log entry as the principal.
+func ClientIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			cert := r.TLS.VerifiedChains[0][0]
+			identity := ClientIdentity{
+				Subject:        cert.Subject.String(),
+				CommonName:     cert.Subject.CommonName,
+				DNSNames:       cert.DNSNames,
+				EmailAddresses: cert.EmailAddresses,
+				SerialNumber:   cert.SerialNumber.String(),
+			}
+			for _, uri := range cert.URIs {
+				identity.URIs = append(identity.URIs, uri.String())
+			}
+			r = r.WithContext(context.WithValue(r.Context(), clientIdentityKey{}, identity))
+			logging.AddFields(r.Context(), logs.Fields{logging.FieldPrincipal: identity.Subject})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetClientIdentity returns verified client identity from the context.
+func GetClientIdentity(ctx context.Context) (ClientIdentity, bool) {
+	identity, ok := ctx.Value(clientIdentityKey{}).(ClientIdentity)
+	return identity, ok
+}
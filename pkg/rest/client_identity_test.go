@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIdentityMiddleware(t *testing.T) {
+	t.Run("puts verified client identity into context", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		spiffe, _ := url.Parse("spiffe://example.org/service")
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+			Subject:      pkix.Name{CommonName: "service", Organization: []string{"Example"}},
+			DNSNames:     []string{"service.example.org"},
+			URIs:         []*url.URL{spiffe},
+			SerialNumber: big.NewInt(42),
+		}}}}
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := GetClientIdentity(r.Context())
+			require.True(t, ok)
+			assert.Equal(t, ClientIdentity{
+				Subject:      "CN=service,O=Example",
+				CommonName:   "service",
+				DNSNames:     []string{"service.example.org"},
+				URIs:         []string{"spiffe://example.org/service"},
+				SerialNumber: "42",
+			}, identity)
+		})
+		ClientIdentityMiddleware(next).ServeHTTP(w, r)
+	})
+	t.Run("no identity without client certificate", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := GetClientIdentity(r.Context())
+			assert.False(t, ok)
+		})
+		ClientIdentityMiddleware(next).ServeHTTP(w, r)
+	})
+}
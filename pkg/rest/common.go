@@ -3,8 +3,9 @@ package rest
 import (
 	"bitbucket.org/creativeadvtech/project-template/pkg/common"
 	"encoding/json"
+	"errors"
 	"github.com/go-chi/chi/v5"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -34,9 +35,13 @@ func ReadHeader(r *http.Request, param string) string {
 }
 
 // ReadBody reads JSON body object from a REST request.
+// It returns 413 error if the body exceeds the limit set by BodyLimit.
 func ReadBody(r *http.Request, object any) error {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
+	body, err := io.ReadAll(r.Body)
+	var errTooLarge *http.MaxBytesError
+	if errors.As(err, &errTooLarge) {
+		return RequestEntityTooLargeErrorf("request body is larger than %d bytes", errTooLarge.Limit).WithError(err)
+	} else if err != nil {
 		return BadRequestErrorf("can't read body").WithError(err)
 	}
 	err = json.Unmarshal(body, object)
@@ -1,11 +1,14 @@
 package rest
 
 import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/getsentry/sentry-go"
+	logs "github.com/sirupsen/logrus"
 	"net/http"
+	"strconv"
 )
 
 // HTTPError is a general error returned by REST API
@@ -16,6 +19,10 @@ type HTTPError struct {
 	// Example: Unexpected internal server error
 	Description string `json:"description"`
 
+	// ID of the request, see RequestID middleware.
+	// Example: 0b5a4ff4-5e3b-4f8b-a7b4-3f4f9f0b6d2a
+	RequestID string `json:"request_id,omitempty"`
+
 	// Wrapped error
 	Err error `json:"-"`
 }
@@ -72,6 +79,11 @@ func ConflictErrorf(format string, args ...any) *HTTPError {
 	return NewHTTPError(http.StatusConflict, format, args...)
 }
 
+// RequestEntityTooLargeErrorf returns REST error with 413 status code and message.
+func RequestEntityTooLargeErrorf(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusRequestEntityTooLarge, format, args...)
+}
+
 // APIHandler is type that extends standard http handler func with error.
 type APIHandler func(w http.ResponseWriter, r *http.Request) error
 
@@ -111,7 +123,7 @@ func MiddlewareHandlerFunc(fn MiddlewareHandler) func(http.Handler) http.Handler
 
 // WriteError logs detailed message and sends encoded error to the client.
 func WriteError(w http.ResponseWriter, r *http.Request, err error) {
-	log := getLogEntry(r)
+	log := logging.FromContext(r.Context())
 	var apiErr *HTTPError
 	if errors.As(err, &apiErr) {
 		entry := log
@@ -123,7 +135,7 @@ func WriteError(w http.ResponseWriter, r *http.Request, err error) {
 		} else {
 			entry.Error(apiErr)
 		}
-		if sendErr := WriteJSON(w, apiErr, apiErr.Code); sendErr != nil {
+		if sendErr := writeHTTPError(w, r, apiErr); sendErr != nil {
 			log.WithError(sendErr).Error(err)
 		}
 	} else {
@@ -133,12 +145,21 @@ func WriteError(w http.ResponseWriter, r *http.Request, err error) {
 		}
 		apiErr = InternalServerErrorf("Internal Server Error")
 		log.WithError(err).Error(apiErr)
-		if sendErr := WriteJSON(w, apiErr, apiErr.Code); sendErr != nil {
+		if sendErr := writeHTTPError(w, r, apiErr); sendErr != nil {
 			log.WithError(sendErr).Error(err)
 		}
 	}
 }
 
+// writeHTTPError sends a copy of the error with the request ID, so the client can refer to the request.
+// The code is added to the request log entry, so the access log shows it.
+func writeHTTPError(w http.ResponseWriter, r *http.Request, apiErr *HTTPError) error {
+	logging.AddFields(r.Context(), logs.Fields{logging.FieldErrorCode: strconv.Itoa(apiErr.Code)})
+	res := *apiErr
+	res.RequestID = GetRequestID(r.Context())
+	return WriteJSON(w, &res, res.Code)
+}
+
 // WriteJSON sends value v to response writer w as JSON.
 func WriteJSON(w http.ResponseWriter, v any, statusCode int) error {
 	js, err := json.Marshal(v)
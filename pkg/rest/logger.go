@@ -1,72 +1,165 @@
 package rest
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"net/netip"
 	"time"
 
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/sirupsen/logrus"
 )
 
+// NewLogger returns request logger with the level and text format.
 func NewLogger(logLevel string) *logrus.Logger {
+	return NewLoggerWithOptions(logging.Options{Level: logLevel})
+}
+
+// NewLoggerWithOptions returns request logger configured with opts.
+func NewLoggerWithOptions(opts logging.Options) *logrus.Logger {
 	logger := logrus.New()
-	ll, err := logrus.ParseLevel(logLevel)
-	if err != nil {
-		ll = logrus.DebugLevel
-	}
-	logger.SetLevel(ll)
-	logger.SetReportCaller(false)
-	logger.SetFormatter(&logrus.TextFormatter{})
+	logging.Configure(logger, opts)
+	logger.AddHook(routeHook{})
 	return logger
 }
 
+// AccessLogOptions are options of the access log.
+type AccessLogOptions struct {
+	// TrustedProxies are networks of proxies, which X-Forwarded-For header is used to get the client IP.
+	TrustedProxies []netip.Prefix
+	// ExcludePaths are paths, e.g. of health probes, which successful requests aren't logged.
+	ExcludePaths []string
+}
+
 // structuredLogger is a adaptor of logrus for chi logging middleware
 type structuredLogger struct {
 	*logrus.Logger
+	opts AccessLogOptions
 }
 
 // RequestLogger returns a logger handler using a custom LogFormatter.
+// The request entry is available to handlers and services with logging.FromContext.
 func RequestLogger(f *logrus.Logger) func(next http.Handler) http.Handler {
-	return middleware.RequestLogger(&structuredLogger{Logger: f})
+	return AccessLogger(f, AccessLogOptions{})
+}
+
+// AccessLogger is RequestLogger with options. Requests are logged on completion at a level chosen by the status:
+// info for 1xx-3xx, warning for 4xx and error for 5xx.
+func AccessLogger(f *logrus.Logger, opts AccessLogOptions) func(next http.Handler) http.Handler {
+	logger := middleware.RequestLogger(&structuredLogger{Logger: f, opts: opts})
+	return func(next http.Handler) http.Handler {
+		return logger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if entry, ok := middleware.GetLogEntry(r).(*structuredLoggerEntry); ok {
+				ctx = logging.WithContext(ctx, entry.entry)
+				entry.ctx = ctx
+				if r.Body != nil && r.Body != http.NoBody {
+					entry.body = &countingBody{ReadCloser: r.Body}
+					r.Body = entry.body
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}))
+	}
 }
 
 // NewLogEntry is called by chi to create log entry on start of each request
 func (l *structuredLogger) NewLogEntry(r *http.Request) middleware.LogEntry {
 	fields := logrus.Fields{
-		"@timestamp": time.Now().UTC().Format(time.RFC3339),
-		"method":     r.Method,
-		"uri":        r.RequestURI,
+		"http.request.method": r.Method,
+		"url.path":            r.URL.Path,
+		"client.ip":           RemoteIP(r, l.opts.TrustedProxies),
+	}
+	if reqID := middleware.GetReqID(r.Context()); reqID != "" {
+		fields[logging.FieldRequestID] = reqID
+	}
+	if ua := r.UserAgent(); ua != "" {
+		fields["user_agent.original"] = ua
 	}
-	return &structuredLoggerEntry{entry: logrus.NewEntry(l.Logger).WithFields(fields)}
+	entry := &structuredLoggerEntry{entry: logrus.NewEntry(l.Logger).WithContext(r.Context()).WithFields(fields)}
+	for _, path := range l.opts.ExcludePaths {
+		if r.URL.Path == path {
+			entry.excluded = true
+			break
+		}
+	}
+	return entry
 }
 
 // structuredLoggerEntry is an adaptor of logrus's entry for chi middleware
 type structuredLoggerEntry struct {
 	entry *logrus.Entry
+	// ctx carries the entry with fields added by inner middlewares
+	ctx      context.Context
+	body     *countingBody
+	excluded bool
 }
 
 // Write is called by chi at the end of each request
 func (l *structuredLoggerEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra any) {
-	l.entry = l.entry.WithFields(logrus.Fields{
-		"status":  status,
-		"elapsed": elapsed.Milliseconds(),
-	})
-	l.entry.Tracef("written %d bytes", bytes)
+	if l.excluded && status < http.StatusBadRequest {
+		return
+	}
+	level := logrus.InfoLevel
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = logrus.ErrorLevel
+	case status >= http.StatusBadRequest:
+		level = logrus.WarnLevel
+	}
+	var bytesIn int64
+	if l.body != nil {
+		bytesIn = l.body.n
+	}
+	l.requestEntry().WithFields(logrus.Fields{
+		"http.response.status_code": status,
+		"http.request.body.bytes":   bytesIn,
+		"http.response.body.bytes":  bytes,
+		"event.duration":            elapsed.Nanoseconds(),
+	}).Log(level, "Request is served.")
 }
 
 // Panic is called by chi's recoverer middleware on panic
 func (l *structuredLoggerEntry) Panic(v any, stack []byte) {
-	l.entry.WithField(logrus.ErrorKey, fmt.Sprintf("%+v", v)).Error(string(stack))
+	l.requestEntry().WithField(logrus.ErrorKey, fmt.Sprintf("%+v", v)).Error(string(stack))
+}
+
+func (l *structuredLoggerEntry) requestEntry() *logrus.Entry {
+	if l.ctx == nil {
+		return l.entry
+	}
+	return logging.FromContext(l.ctx)
+}
+
+// countingBody counts bytes read from the request body.
+type countingBody struct {
+	io.ReadCloser
+	n int64
 }
 
-// getLogEntry returns request logger
-func getLogEntry(r *http.Request) *logrus.Entry {
-	if log, ok := r.Context().Value(middleware.LogEntryCtxKey).(*structuredLoggerEntry); ok {
-		return log.entry
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// routeHook adds the matched route pattern, which is known only after routing.
+type routeHook struct{}
+
+func (routeHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (routeHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if rctx := chi.RouteContext(entry.Context); rctx != nil && rctx.RoutePattern() != "" {
+		entry.Data[logging.FieldRoute] = rctx.RoutePattern()
 	}
-	log := logrus.New()
-	log.SetOutput(ioutil.Discard)
-	return logrus.NewEntry(log)
+	return nil
 }
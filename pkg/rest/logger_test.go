@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	assert.Equal(t, logrus.WarnLevel, NewLogger("warn").GetLevel())
+	assert.Equal(t, logrus.DebugLevel, NewLogger("unknown").GetLevel())
+}
+
+func TestAccessLogger(t *testing.T) {
+	opts := AccessLogOptions{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		ExcludePaths:   []string{"/status"},
+	}
+	serve := func(r *http.Request, handler APIHandler) map[string]any {
+		var buf bytes.Buffer
+		logger := NewLoggerWithOptions(logging.Options{Level: "info", Format: logging.FormatJSON})
+		logger.SetOutput(&buf)
+		AccessLogger(logger, opts)(handler).ServeHTTP(httptest.NewRecorder(), r)
+
+		if buf.Len() == 0 {
+			return nil
+		}
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		// the access log is the last line, WriteError logs before it
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+		return entry
+	}
+
+	t.Run("logs request details", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/objects?limit=1", strings.NewReader(`{"data":"x"}`))
+		r.RemoteAddr = "10.0.0.2:41000"
+		r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+		r.Header.Set("User-Agent", "test-agent")
+
+		entry := serve(r, func(w http.ResponseWriter, r *http.Request) error {
+			_, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+			return nil
+		})
+
+		assert.Equal(t, "info", entry[logging.FieldLevel])
+		assert.Equal(t, "Request is served.", entry[logging.FieldMessage])
+		assert.Equal(t, "POST", entry["http.request.method"])
+		assert.Equal(t, "/v1/objects", entry["url.path"])
+		assert.NotContains(t, entry, "url.original", "the query may contain secrets")
+		assert.Equal(t, "203.0.113.7", entry["client.ip"])
+		assert.Equal(t, "test-agent", entry["user_agent.original"])
+		assert.Equal(t, float64(http.StatusCreated), entry["http.response.status_code"])
+		assert.Equal(t, float64(12), entry["http.request.body.bytes"])
+		assert.Equal(t, float64(7), entry["http.response.body.bytes"])
+		assert.Contains(t, entry, "event.duration")
+	})
+	t.Run("level by status", func(t *testing.T) {
+		tests := []struct {
+			err   error
+			level string
+			code  any
+		}{
+			{err: NotFoundErrorf("not found"), level: "warning", code: "404"},
+			{err: errors.New("unexpected"), level: "error", code: "500"},
+		}
+		for _, tt := range tests {
+			entry := serve(httptest.NewRequest(http.MethodGet, "/v1/objects", nil), func(w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			})
+			assert.Equal(t, tt.level, entry[logging.FieldLevel])
+			assert.Equal(t, tt.code, entry[logging.FieldErrorCode])
+		}
+	})
+	t.Run("excludes successful requests to paths", func(t *testing.T) {
+		entry := serve(httptest.NewRequest(http.MethodGet, "/status", nil), func(w http.ResponseWriter, r *http.Request) error {
+			return nil
+		})
+		assert.Nil(t, entry)
+
+		entry = serve(httptest.NewRequest(http.MethodGet, "/status", nil), func(w http.ResponseWriter, r *http.Request) error {
+			return NewHTTPError(http.StatusServiceUnavailable, "not ready")
+		})
+		assert.Equal(t, float64(http.StatusServiceUnavailable), entry["http.response.status_code"])
+	})
+}
+
+func TestRequestLogger_Context(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerWithOptions(logging.Options{Level: "trace", Format: logging.FormatJSON})
+	logger.SetOutput(&buf)
+	hub, err := NewSentryHub(SentryOptions{})
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	router.Use(RequestID, RequestLogger(logger), SentryHubMiddleware(hub), ClientIdentityMiddleware)
+	router.Route("/v1", func(r chi.Router) {
+		r.Delete("/objects/{id}", func(w http.ResponseWriter, r *http.Request) {
+			logging.FromContext(r.Context()).Info("deleting")
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+	r := httptest.NewRequest(http.MethodDelete, "/v1/objects/42", nil)
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+		Subject:      pkix.Name{CommonName: "service"},
+		SerialNumber: big.NewInt(42),
+	}}}}
+
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	for i, msg := range []string{"deleting", "Request is served."} {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(lines[i], &entry))
+		assert.Equal(t, msg, entry[logging.FieldMessage])
+		assert.NotEmpty(t, entry[logging.FieldRequestID])
+		assert.Equal(t, "/v1/objects/{id}", entry[logging.FieldRoute])
+		assert.Equal(t, "CN=service", entry[logging.FieldPrincipal])
+		assert.Len(t, entry[logging.FieldTraceID], 32)
+	}
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/tracing"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"net/http/httputil"
@@ -30,7 +31,8 @@ func (p *Proxy) AddDefaultOptions(opts ...Option) {
 	}
 }
 
-// ProxyRequest performs request.
+// ProxyRequest performs request. X-Request-ID header with the request ID is forwarded to upstreams.
+// Upstream calls are traced with client spans, which trace context is propagated with traceparent header.
 func (p *Proxy) ProxyRequest(method, origPath string, opts ...Option) {
 	proxyData := proxyRequestData{}
 	for _, opt := range opts {
@@ -38,7 +40,12 @@ func (p *Proxy) ProxyRequest(method, origPath string, opts ...Option) {
 	}
 	p.Mux.Method(method, origPath,
 		&httputil.ReverseProxy{
+			Transport: &tracing.Transport{},
 			Director: func(req *http.Request) {
+				// forwarded by default, a director can remove or replace it
+				if id := GetRequestID(req.Context()); id != "" {
+					req.Header.Set(RequestIDHeader, id)
+				}
 				for _, director := range p.defaultRequestData.directors {
 					director(req)
 				}
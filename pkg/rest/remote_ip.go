@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RemoteIP returns IP address of the client. X-Forwarded-For header is honored only when the request comes
+// from a trusted proxy: the rightmost address not belonging to trustedProxies is returned.
+func RemoteIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !trusted(ip, trustedProxies) {
+		return host
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			// the header is forged or malformed beyond this point
+			break
+		}
+		ip = hop
+		if !trusted(hop, trustedProxies) {
+			break
+		}
+	}
+	return ip.Unmap().String()
+}
+
+func trusted(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	ip = ip.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
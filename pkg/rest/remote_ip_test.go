@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		ip         string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:1234", ip: "203.0.113.7"},
+		{name: "untrusted proxy", remoteAddr: "198.51.100.1:1234", forwarded: []string{"203.0.113.7"}, ip: "198.51.100.1"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", forwarded: []string{"203.0.113.7"}, ip: "203.0.113.7"},
+		{name: "forged hops", remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.1.1.1, 203.0.113.7", "10.0.0.2"}, ip: "203.0.113.7"},
+		{name: "malformed hop", remoteAddr: "10.0.0.1:1234", forwarded: []string{"garbage, 10.0.0.2"}, ip: "10.0.0.2"},
+		{name: "IPv6 proxy", remoteAddr: "[fd00::1]:1234", forwarded: []string{"2001:db8::7"}, ip: "2001:db8::7"},
+		{name: "no header", remoteAddr: "10.0.0.1:1234", ip: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			assert.Equal(t, tt.ip, RemoteIP(r, trusted))
+		})
+	}
+}
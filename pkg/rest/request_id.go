@@ -0,0 +1,31 @@
+package rest
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// RequestIDHeader is a header with the request ID. It's accepted from clients, returned in responses
+// and forwarded to upstreams by Proxy.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID puts the request ID into the request context and the response header.
+// The ID is taken from X-Request-ID header when it's a valid UUID, otherwise a new UUIDv4 is generated.
+// It should go before RequestLogger, so the ID is logged.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(r.Header.Get(RequestIDHeader))
+		if err != nil {
+			id = uuid.New()
+		}
+		w.Header().Set(RequestIDHeader, id.String())
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, id.String())))
+	})
+}
+
+// GetRequestID returns the request ID set by RequestID middleware or an empty string.
+func GetRequestID(ctx context.Context) string {
+	return middleware.GetReqID(ctx)
+}
@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	const clientID = "0b5a4ff4-5e3b-4f8b-a7b4-3f4f9f0b6d2a"
+	tests := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{name: "accepts client UUID", header: clientID, reused: true},
+		{name: "generates missing ID", header: ""},
+		{name: "replaces invalid ID", header: "id\nforged=log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(RequestIDHeader, tt.header)
+
+			var id string
+			RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = GetRequestID(r.Context())
+			})).ServeHTTP(w, r)
+
+			assert.Equal(t, id, w.Header().Get(RequestIDHeader))
+			if tt.reused {
+				assert.Equal(t, clientID, id)
+				return
+			}
+			parsed, err := uuid.Parse(id)
+			require.NoError(t, err)
+			assert.Equal(t, uuid.Version(4), parsed.Version())
+		})
+	}
+}
+
+func TestRequestID_Propagation(t *testing.T) {
+	const id = "0b5a4ff4-5e3b-4f8b-a7b4-3f4f9f0b6d2a"
+
+	t.Run("error body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(RequestIDHeader, id)
+
+		RequestID(APIHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return errors.New("unexpected error")
+		})).ServeHTTP(w, r)
+
+		assert.JSONEq(t, `{"code": 500, "description": "Internal Server Error", "request_id": "`+id+`"}`, w.Body.String())
+	})
+	t.Run("proxied upstream", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(r.Header.Get(RequestIDHeader))
+		}))
+		defer upstream.Close()
+		upstreamURL, err := url.Parse(upstream.URL)
+		require.NoError(t, err)
+		proxy := NewProxy(chi.NewRouter(), Director(SetBaseURL(upstreamURL)))
+		proxy.Use(RequestID)
+		proxy.ProxyRequest(http.MethodGet, "/")
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(RequestIDHeader, id)
+
+		proxy.ServeHTTP(w, r)
+
+		assert.JSONEq(t, `"`+id+`"`, w.Body.String())
+	})
+}
@@ -1,30 +1,87 @@
 package rest
 
 import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
 	"github.com/getsentry/sentry-go"
 	sentryhttp "github.com/getsentry/sentry-go/http"
+	logs "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 	"log"
 	"net/http"
 	"time"
 )
 
-func SentryMiddleware(dsn, env string, debug bool, release string) func(next http.Handler) http.Handler {
-	client, err := sentry.NewClient(sentry.ClientOptions{
-		Dsn:              dsn,
+// SentryOptions are options of a sentry client.
+type SentryOptions struct {
+	DSN              string
+	Env              string
+	Debug            bool
+	Release          string
+	SampleRate       float64 // sample rate of error events, 0 is treated as 1
+	TracesSampleRate float64
+	// Redactor redacts events before they are sent.
+	Redactor *redact.Redactor
+}
+
+// NewSentryClient returns a new sentry client.
+func NewSentryClient(opts SentryOptions) (*sentry.Client, error) {
+	var beforeSend func(*sentry.Event, *sentry.EventHint) *sentry.Event
+	if opts.Redactor != nil {
+		beforeSend = func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			return redactEvent(opts.Redactor, event)
+		}
+	}
+	return sentry.NewClient(sentry.ClientOptions{
+		Dsn:              opts.DSN,
 		AttachStacktrace: true,
-		Environment:      env,
-		Release:          release,
-		Debug:            debug,
+		Environment:      opts.Env,
+		Release:          opts.Release,
+		Debug:            opts.Debug,
+		SampleRate:       opts.SampleRate,
+		TracesSampleRate: opts.TracesSampleRate,
+		BeforeSend:       beforeSend,
 	})
+}
+
+// NewSentryHub returns a hub bound to a new sentry client.
+// The client can be replaced with hub.BindClient, e.g. to apply new sample rates.
+func NewSentryHub(opts SentryOptions) (*sentry.Hub, error) {
+	client, err := NewSentryClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return sentry.NewHub(client, sentry.NewScope()), nil
+}
+
+func SentryMiddleware(dsn, env string, debug bool, release string) func(next http.Handler) http.Handler {
+	hub, err := NewSentryHub(SentryOptions{DSN: dsn, Env: env, Debug: debug, Release: release})
 	if err != nil {
 		log.Fatal(err)
 	}
-	hub := sentry.NewHub(client, sentry.NewScope())
+	return SentryHubMiddleware(hub)
+}
+
+// SentryHubMiddleware sets the hub on the request context and reports panics to it.
+// The hub is cloned per request, so the scope is tagged with the request ID.
+// The trace ID of the request transaction is added to the request log entry unless the request is traced
+// with OpenTelemetry, see tracing.Middleware.
+func SentryHubMiddleware(hub *sentry.Hub) func(next http.Handler) http.Handler {
 	sentryHandler := sentryhttp.New(sentryhttp.Options{Repanic: true, Timeout: time.Minute, WaitForDelivery: true})
 	handler := func(next http.Handler) http.Handler {
+		traced := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if span := sentry.TransactionFromContext(r.Context()); span != nil && !trace.SpanContextFromContext(r.Context()).IsValid() {
+				logging.AddFields(r.Context(), logs.Fields{logging.FieldTraceID: span.TraceID.String()})
+			}
+			next.ServeHTTP(w, r)
+		})
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			r = r.WithContext(sentry.SetHubOnContext(r.Context(), hub))
-			sentryHandler.Handle(next).ServeHTTP(w, r)
+			reqHub := hub.Clone()
+			if id := GetRequestID(r.Context()); id != "" {
+				reqHub.Scope().SetTag("request_id", id)
+			}
+			r = r.WithContext(sentry.SetHubOnContext(r.Context(), reqHub))
+			sentryHandler.Handle(traced).ServeHTTP(w, r)
 		})
 	}
 	return handler
@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	"github.com/getsentry/sentry-go"
+)
+
+// redactEvent redacts messages, request details and extra data of the event before it's sent.
+func redactEvent(r *redact.Redactor, event *sentry.Event) *sentry.Event {
+	event.Message = r.String(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = r.String(event.Exception[i].Value)
+	}
+	if req := event.Request; req != nil {
+		req.URL = r.String(req.URL)
+		req.QueryString = r.String(req.QueryString)
+		req.Data = r.String(req.Data)
+		req.Cookies = r.HeaderValue("Cookie", req.Cookies)
+		for name, value := range req.Headers {
+			req.Headers[name] = r.HeaderValue(name, value)
+		}
+	}
+	for _, breadcrumb := range event.Breadcrumbs {
+		breadcrumb.Message = r.String(breadcrumb.Message)
+		redactData(r, breadcrumb.Data)
+	}
+	redactData(r, event.Extra)
+	return event
+}
+
+func redactData(r *redact.Redactor, data map[string]any) {
+	for key, value := range data {
+		if r.Field(key) {
+			data[key] = redact.Mask
+		} else if s, ok := value.(string); ok {
+			data[key] = r.String(s)
+		}
+	}
+}
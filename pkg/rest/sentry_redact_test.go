@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"testing"
+
+	"bitbucket.org/creativeadvtech/project-template/pkg/redact"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactEvent(t *testing.T) {
+	redactor, err := redact.New(redact.Options{Headers: []string{"Authorization", "Cookie"}, Fields: []string{"password"}})
+	require.NoError(t, err)
+	event := &sentry.Event{
+		Message:   "login failed: Bearer abc",
+		Exception: []sentry.Exception{{Value: "dial postgres://app:s3cret@db/app"}},
+		Request: &sentry.Request{
+			URL:         "https://example.com/v1/login",
+			QueryString: "password=s3cret",
+			Data:        `{"login": "a", "password": "s3cret"}`,
+			Cookies:     "session=abc",
+			Headers:     map[string]string{"Authorization": "Basic abc", "Accept": "application/json"},
+		},
+		Breadcrumbs: []*sentry.Breadcrumb{{Message: "retry with Bearer abc", Data: map[string]any{"password": "s3cret"}}},
+		Extra:       map[string]any{"body": `{"password": "s3cret"}`, "attempt": 2},
+	}
+
+	event = redactEvent(redactor, event)
+
+	assert.Equal(t, "login failed: Bearer ******", event.Message)
+	assert.Equal(t, "dial postgres://app:******@db/app", event.Exception[0].Value)
+	assert.Equal(t, &sentry.Request{
+		URL:         "https://example.com/v1/login",
+		QueryString: "password=******",
+		Data:        `{"login":"a","password":"******"}`,
+		Cookies:     redact.Mask,
+		Headers:     map[string]string{"Authorization": redact.Mask, "Accept": "application/json"},
+	}, event.Request)
+	assert.Equal(t, "retry with Bearer ******", event.Breadcrumbs[0].Message)
+	assert.Equal(t, map[string]any{"password": redact.Mask}, event.Breadcrumbs[0].Data)
+	assert.Equal(t, map[string]any{"body": `{"password":"******"}`, "attempt": 2}, event.Extra)
+}
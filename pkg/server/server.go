@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logs "github.com/sirupsen/logrus"
+)
+
+// ShutdownHook is called after the server has stopped accepting requests and drained in-flight ones.
+type ShutdownHook func(ctx context.Context) error
+
+// Server is an HTTP server with graceful shutdown.
+type Server struct {
+	*http.Server
+
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	ready           atomic.Bool
+	mu              sync.Mutex
+	hooks           []ShutdownHook
+}
+
+// New wraps the HTTP server. On shutdown the server keeps accepting requests for shutdownDelay while readiness is
+// failing, so load balancers can stop routing to it, then in-flight requests are drained for at most shutdownTimeout.
+func New(srv *http.Server, shutdownDelay, shutdownTimeout time.Duration) *Server {
+	return &Server{
+		Server:          srv,
+		shutdownDelay:   shutdownDelay,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// Ready reports whether the server accepts new requests. It turns false as soon as shutdown starts.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// OnShutdown registers a hook. Hooks are called in the order of registration after the requests are drained.
+func (s *Server) OnShutdown(hook ShutdownHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// Run listens on the server address and serves requests until ctx is done, then shuts the server down.
+func (s *Server) Run(ctx context.Context) error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves requests on the listener until ctx is done, then shuts the server down.
+// TLS is used when TLSConfig is set.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	s.ready.Store(true)
+	go func() {
+		if s.TLSConfig != nil {
+			// certificates are provided by TLSConfig
+			errCh <- s.Server.ServeTLS(ln, "", "")
+		} else {
+			errCh <- s.Server.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		s.ready.Store(false)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		if hooksErr := s.runHooks(); err == nil {
+			err = hooksErr
+		}
+		return err
+	case <-ctx.Done():
+	}
+	err := s.Shutdown()
+	// the listener is closed when serving returns
+	<-errCh
+	return err
+}
+
+// Shutdown flips readiness, stops accepting new connections, waits for in-flight requests and calls shutdown hooks.
+// The first error is returned.
+func (s *Server) Shutdown() error {
+	s.ready.Store(false)
+	if s.shutdownDelay > 0 {
+		logs.Infof("Readiness is failing, waiting %s before shutdown.", s.shutdownDelay)
+		time.Sleep(s.shutdownDelay)
+	}
+	logs.Infof("Shutting down the server, draining requests for up to %s.", s.shutdownTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	err := s.Server.Shutdown(ctx)
+	if err != nil {
+		logs.Errorf("Can't drain requests; error: %v", err)
+		_ = s.Server.Close()
+	}
+	if hooksErr := s.runHooks(); err == nil {
+		err = hooksErr
+	}
+	return err
+}
+
+// Release calls shutdown hooks without serving, e.g. when the app fails to start, so resources are released.
+// The first error is returned.
+func (s *Server) Release() error {
+	return s.runHooks()
+}
+
+// runHooks calls shutdown hooks in order, each one gets its own shutdownTimeout.
+// All hooks are called even if some of them fail, the first error is returned.
+func (s *Server) runHooks() error {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, hook := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		err := hook(ctx)
+		cancel()
+		if err != nil {
+			logs.Errorf("Shutdown hook failed; error: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, handler http.Handler, shutdownDelay time.Duration) (*Server, string, context.CancelFunc, chan error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := New(&http.Server{Handler: handler}, shutdownDelay, 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ctx, ln)
+	}()
+	require.Eventually(t, srv.Ready, time.Second, 10*time.Millisecond)
+	return srv, "http://" + ln.Addr().String(), cancel, done
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	t.Run("drains in-flight requests", func(t *testing.T) {
+		started := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(300 * time.Millisecond)
+			_, _ = w.Write([]byte("done"))
+		})
+		srv, url, cancel, done := startServer(t, handler, 0)
+
+		type result struct {
+			body string
+			err  error
+		}
+		resCh := make(chan result, 1)
+		go func() {
+			res, err := http.Get(url)
+			if err != nil {
+				resCh <- result{err: err}
+				return
+			}
+			defer res.Body.Close()
+			body, err := ioutil.ReadAll(res.Body)
+			resCh <- result{body: string(body), err: err}
+		}()
+		<-started
+		cancel()
+
+		res := <-resCh
+		require.NoError(t, res.err)
+		assert.Equal(t, "done", res.body)
+		require.NoError(t, <-done)
+		assert.False(t, srv.Ready())
+
+		_, err := http.Get(url)
+		assert.Error(t, err, "new connections must be refused")
+	})
+
+	t.Run("calls hooks in order after draining", func(t *testing.T) {
+		var finished atomic.Bool
+		started := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			finished.Store(true)
+		})
+		srv, url, cancel, done := startServer(t, handler, 0)
+		var calls []string
+		srv.OnShutdown(func(ctx context.Context) error {
+			assert.True(t, finished.Load(), "requests must be drained before hooks")
+			calls = append(calls, "sentry")
+			return nil
+		})
+		srv.OnShutdown(func(ctx context.Context) error {
+			calls = append(calls, "db")
+			return errors.New("close error")
+		})
+
+		go func() {
+			if res, err := http.Get(url); err == nil {
+				res.Body.Close()
+			}
+		}()
+		<-started
+		cancel()
+
+		require.EqualError(t, <-done, "close error")
+		assert.Equal(t, []string{"sentry", "db"}, calls)
+	})
+
+	t.Run("fails readiness during shutdown delay", func(t *testing.T) {
+		srv, url, cancel, done := startServer(t, http.NotFoundHandler(), 200*time.Millisecond)
+		cancel()
+
+		require.Eventually(t, func() bool { return !srv.Ready() }, time.Second, 10*time.Millisecond)
+		res, err := http.Get(url)
+		require.NoError(t, err, "requests are still served during the delay")
+		res.Body.Close()
+		require.NoError(t, <-done)
+	})
+}
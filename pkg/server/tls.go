@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	logs "github.com/sirupsen/logrus"
+)
+
+// Client authentication modes.
+const (
+	ClientAuthNone     = "none"     // client certificates are not requested
+	ClientAuthOptional = "optional" // client certificates are verified if given
+	ClientAuthRequire  = "require"  // valid client certificate is required
+)
+
+// Cipher policies. TLS 1.3 cipher suites are not configurable.
+const (
+	CipherPolicyDefault = "default" // Go defaults
+	CipherPolicyModern  = "modern"  // only ECDHE key exchange with AEAD ciphers for TLS 1.2
+)
+
+var modernCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions describes server certificate and client certificate verification.
+type TLSOptions struct {
+	CertFile     string
+	KeyFile      string
+	MinVersion   string // 1.0, 1.1, 1.2 or 1.3
+	CipherPolicy string
+	ClientCAFile string // CA bundle to verify client certificates
+	ClientAuth   string
+}
+
+// CertReloader keeps the certificate and client CA bundle loaded from files and reloads them when files change.
+type CertReloader struct {
+	opts TLSOptions
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// NewTLSConfig returns TLS configuration with certificates loaded by the returned reloader.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, *CertReloader, error) {
+	minVersion, ok := tlsVersions[opts.MinVersion]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown TLS version %q", opts.MinVersion)
+	}
+	cfg := &tls.Config{MinVersion: minVersion}
+
+	switch opts.CipherPolicy {
+	case CipherPolicyDefault, "":
+	case CipherPolicyModern:
+		cfg.CipherSuites = modernCipherSuites
+	default:
+		return nil, nil, fmt.Errorf("unknown cipher policy %q", opts.CipherPolicy)
+	}
+
+	switch opts.ClientAuth {
+	case ClientAuthNone, "":
+		cfg.ClientAuth = tls.NoClientCert
+	case ClientAuthOptional:
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, nil, fmt.Errorf("unknown client auth mode %q", opts.ClientAuth)
+	}
+	if cfg.ClientAuth != tls.NoClientCert && opts.ClientCAFile == "" {
+		return nil, nil, fmt.Errorf("client CA file is required for client auth mode %q", opts.ClientAuth)
+	}
+
+	reloader := &CertReloader{opts: opts, modTimes: make(map[string]time.Time)}
+	if err := reloader.Reload(); err != nil {
+		return nil, nil, err
+	}
+	cfg.GetCertificate = reloader.getCertificate
+	if cfg.ClientAuth != tls.NoClientCert {
+		// client CAs can't be fetched per handshake, so the config is cloned with the current bundle
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			clientCfg := cfg.Clone()
+			clientCfg.GetConfigForClient = nil
+			clientCfg.ClientCAs = reloader.getClientCAs()
+			return clientCfg, nil
+		}
+	}
+	return cfg, reloader, nil
+}
+
+// Reload loads the certificate and client CA bundle if any of the files was modified since the last load.
+// The previous certificates are kept when loading fails.
+func (r *CertReloader) Reload() error {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+	modTimes := make(map[string]time.Time, len(files))
+	changed := false
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+		r.mu.RLock()
+		changed = changed || !info.ModTime().Equal(r.modTimes[file])
+		r.mu.RUnlock()
+	}
+	if !changed {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("can't load certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("can't read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.opts.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	return nil
+}
+
+// Watch reloads certificates every interval until ctx is done.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(); err != nil {
+				logs.Errorf("Can't reload TLS certificates; error: %v", err)
+			}
+		}
+	}
+}
+
+func (r *CertReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *CertReloader) getClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.clientCAs
+}
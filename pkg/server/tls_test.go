@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert issues a certificate signed by parent, or a self-signed CA when parent is nil.
+func newTestCert(t *testing.T, cn string, serial int64, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	if keyFile == "" {
+		return
+	}
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	ca := newTestCert(t, "test CA", 1, nil)
+	ca.write(t, caFile, "")
+	newTestCert(t, "server", 2, ca).write(t, certFile, keyFile)
+
+	t.Run("requires verified client certificate", func(t *testing.T) {
+		tlsCfg, _, err := NewTLSConfig(TLSOptions{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			MinVersion:   "1.2",
+			CipherPolicy: CipherPolicyModern,
+			ClientCAFile: caFile,
+			ClientAuth:   ClientAuthRequire,
+		})
+		require.NoError(t, err)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+		})
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		srv := New(&http.Server{Handler: handler, TLSConfig: tlsCfg}, 0, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = srv.Serve(ctx, ln) }()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		client := func(certs ...tls.Certificate) *http.Client {
+			return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+		}
+		url := "https://" + ln.Addr().String()
+
+		res, err := client(newTestCert(t, "client", 3, ca).tlsCertificate()).Get(url)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "client", string(body))
+
+		_, err = client().Get(url)
+		assert.Error(t, err, "client without certificate must be rejected")
+
+		_, err = client(newTestCert(t, "stranger", 4, nil).tlsCertificate()).Get(url)
+		assert.Error(t, err, "client with certificate from unknown CA must be rejected")
+	})
+
+	t.Run("reloads modified certificate", func(t *testing.T) {
+		tlsCfg, reloader, err := NewTLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2"})
+		require.NoError(t, err)
+		cert, err := tlsCfg.GetCertificate(nil)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), leaf.SerialNumber.Int64())
+
+		newTestCert(t, "server", 5, ca).write(t, certFile, keyFile)
+		modTime := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+		require.NoError(t, reloader.Reload())
+
+		cert, err = tlsCfg.GetCertificate(nil)
+		require.NoError(t, err)
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), leaf.SerialNumber.Int64())
+	})
+
+	t.Run("validates options", func(t *testing.T) {
+		_, _, err := NewTLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, MinVersion: "2.0"})
+		assert.EqualError(t, err, `unknown TLS version "2.0"`)
+		_, _, err = NewTLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2", ClientAuth: ClientAuthRequire})
+		assert.EqualError(t, err, `client CA file is required for client auth mode "require"`)
+	})
+}
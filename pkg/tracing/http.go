@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	logs "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Middleware starts a server span per request continuing the trace of traceparent header. The span is named by
+// the method and chi route pattern, e.g. GET /v1/objects/{ObjectID}, once the request is routed.
+// Trace and span IDs are added to the request log entry, so it should go after rest.AccessLogger.
+// It should go after middleware.Recoverer to mark spans of panicking requests as errors.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(httpconv.ServerRequest("", r)...),
+		)
+		defer span.End()
+		if sc := span.SpanContext(); sc.IsValid() {
+			logging.AddFields(ctx, logs.Fields{logging.FieldTraceID: sc.TraceID().String(), logging.FieldSpanID: sc.SpanID().String()})
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route := rctx.RoutePattern()
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRouteKey.String(route))
+			}
+			// the panic is recovered by middleware.Recoverer, which responds with 500
+			if rec := recover(); rec != nil {
+				span.SetAttributes(semconv.HTTPStatusCodeKey.Int(http.StatusInternalServerError))
+				span.SetStatus(codes.Error, fmt.Sprint("panic: ", rec))
+				panic(rec)
+			}
+			status := ww.Status()
+			if status == 0 {
+				// nothing is written
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+			span.SetStatus(httpconv.ServerStatus(status))
+		}()
+		next.ServeHTTP(ww, r.WithContext(ctx))
+	})
+}
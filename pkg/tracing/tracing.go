@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"os"
+)
+
+// TracerName is the instrumentation name of spans started by the app.
+const TracerName = "bitbucket.org/creativeadvtech/project-template"
+
+// Exporters of spans.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Options are options of tracing.
+type Options struct {
+	// Exporter is one of ExporterNone, ExporterOTLP, ExporterStdout or ExporterFile.
+	Exporter string
+	// OTLPEndpoint is host:port of an OTLP gRPC collector, OTEL_EXPORTER_OTLP_* variables are used when it's empty.
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// File is the file spans are appended to by ExporterFile.
+	File string
+	// SampleRatio is the ratio of sampled traces which aren't sampled by the caller, see sdktrace.ParentBased.
+	SampleRatio float64
+
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+}
+
+// Init sets the global tracer provider and W3C trace context propagator. The returned shutdown function flushes
+// spans and releases the exporter. With ExporterNone spans aren't recorded, but trace context is propagated.
+func Init(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if opts.Exporter == ExporterNone || opts.Exporter == "" {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closer, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String(opts.ServiceName),
+		semconv.ServiceVersionKey.String(opts.ServiceVersion),
+		semconv.DeploymentEnvironmentKey.String(opts.Environment),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// newExporter returns the exporter and the file it writes to, if any.
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case ExporterOTLP:
+		var clientOpts []otlptracegrpc.Option
+		if opts.OTLPEndpoint != "" {
+			clientOpts = append(clientOpts, otlptracegrpc.WithEndpoint(opts.OTLPEndpoint))
+		}
+		if opts.OTLPInsecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, clientOpts...)
+		return exporter, nil, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case ExporterFile:
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, nil, err
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter: %s", opts.Exporter)
+	}
+}
+
+// Tracer returns the tracer of the app from the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
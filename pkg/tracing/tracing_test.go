@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bitbucket.org/creativeadvtech/project-template/pkg/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	logs "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record sets a global tracer provider recording spans for the test.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+	return recorder
+}
+
+func TestInit(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "traces.json")
+		shutdown, err := Init(context.Background(), Options{Exporter: ExporterFile, File: file, SampleRatio: 1, ServiceName: "test"})
+		require.NoError(t, err)
+
+		_, span := Tracer().Start(context.Background(), "test-span")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"Name":"test-span"`)
+		assert.Contains(t, string(data), `"Value":"test"`)
+	})
+
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := Init(context.Background(), Options{Exporter: "zipkin"})
+		assert.EqualError(t, err, "unknown trace exporter: zipkin")
+	})
+
+	t.Run("none", func(t *testing.T) {
+		shutdown, err := Init(context.Background(), Options{Exporter: ExporterNone})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+		_, span := Tracer().Start(context.Background(), "test-span")
+		assert.False(t, span.IsRecording())
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := record(t)
+	var fields logs.Fields
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := logging.WithContext(r.Context(), logs.NewEntry(logs.New()))
+			next.ServeHTTP(w, r.WithContext(ctx))
+			fields = logging.FromContext(ctx).Data
+		})
+	})
+	router.Use(Middleware)
+	router.Get("/v1/objects/{ObjectID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	r := httptest.NewRequest(http.MethodGet, "/v1/objects/1", nil)
+	r.Header.Set("traceparent", parent)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /v1/objects/{ObjectID}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), semconv.HTTPRouteKey.String("/v1/objects/{ObjectID}"))
+	assert.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusInternalServerError))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields[logging.FieldTraceID])
+	assert.Equal(t, span.SpanContext().SpanID().String(), fields[logging.FieldSpanID])
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	recorder := record(t)
+	router := chi.NewRouter()
+	router.Use(middleware.Recoverer)
+	router.Use(Middleware)
+	router.Get("/v1/objects", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/objects", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /v1/objects", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "panic: boom", spans[0].Status().Description)
+	assert.Contains(t, spans[0].Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusInternalServerError))
+}
+
+func TestTransport(t *testing.T) {
+	recorder := record(t)
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer upstream.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/objects", nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: &Transport{}}).Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "HTTP GET", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Equal(t, "00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01", traceparent)
+	assert.Empty(t, req.Header.Get("traceparent"), "the request isn't modified")
+}